@@ -3,44 +3,177 @@ package cronx
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/rizalgowandy/cronx/storage"
 	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
 	"github.com/rizalgowandy/gdk/pkg/logx"
 )
 
+// AlertEvent describes the kind of unwanted event that happens to a job.
+type AlertEvent string
+
+func (a AlertEvent) String() string {
+	return string(a)
+}
+
+const (
+	// AlertEventFailure describes that a run has failed.
+	AlertEventFailure AlertEvent = "FAILURE"
+	// AlertEventRecovery describes that a run has succeeded after the previous run failed.
+	AlertEventRecovery AlertEvent = "RECOVERY"
+	// AlertEventConsecutiveFailure describes that the job has failed a number of times in a row.
+	AlertEventConsecutiveFailure AlertEvent = "CONSECUTIVE_FAILURE"
+	// AlertEventTimeout describes that a run has exceeded its deadline.
+	AlertEventTimeout AlertEvent = "TIMEOUT"
+	// AlertEventMissedSchedule describes that a run could not start on schedule,
+	// because the previous run is still running.
+	AlertEventMissedSchedule AlertEvent = "MISSED_SCHEDULE"
+	// AlertEventHighLatency describes that a run has not finished before a new run for next schedule is started.
+	AlertEventHighLatency AlertEvent = "HIGH_LATENCY"
+	// AlertEventDown describes that the job has failed to be registered.
+	AlertEventDown AlertEvent = "DOWN"
+	// AlertEventPanic describes that a run has panicked.
+	AlertEventPanic AlertEvent = "PANIC"
+	// AlertEventOverdue describes that the job has not succeeded within its success window.
+	AlertEventOverdue AlertEvent = "OVERDUE"
+	// AlertEventBroken describes that the circuit breaker of the job has been opened,
+	// and the runs are skipped until the cooldown has passed.
+	AlertEventBroken AlertEvent = "BROKEN"
+	// AlertEventHighQueueWait describes that a run has waited too long for a worker.
+	AlertEventHighQueueWait AlertEvent = "HIGH_QUEUE_WAIT"
+)
+
+// Alert describes an unwanted event that happens to a job.
+type Alert struct {
+	// Event is the kind of the event.
+	Event AlertEvent
+	// Job is the job where the event happens.
+	Job *Job
+	// History is the run history where the event happens, if any.
+	History *storage.History
+	// Err is the error that causes the event, if any.
+	Err error
+	// ConsecutiveFailures is the number of failed runs in a row when the event happens.
+	ConsecutiveFailures int64
+	// Fields contains additional information about the event.
+	Fields errorx.Fields
+	// Time is when the event happens.
+	Time time.Time
+}
+
+// Message returns a human-readable summary of the alert.
+func (a *Alert) Message() string {
+	name := ""
+	if a.Job != nil {
+		name = a.Job.Name
+	}
+
+	switch a.Event {
+	case AlertEventFailure:
+		return fmt.Sprintf("Operation cron %s has failed", name)
+	case AlertEventRecovery:
+		return fmt.Sprintf("Operation cron %s has recovered", name)
+	case AlertEventConsecutiveFailure:
+		return fmt.Sprintf("Operation cron %s has failed %d times in a row", name, a.ConsecutiveFailures)
+	case AlertEventTimeout:
+		return fmt.Sprintf("Operation cron %s has timed out", name)
+	case AlertEventMissedSchedule:
+		return fmt.Sprintf("Operation cron %s has missed its schedule", name)
+	case AlertEventHighLatency:
+		return fmt.Sprintf("Operation cron %s has high latency", name)
+	case AlertEventDown:
+		return fmt.Sprintf("Operation cron %s has failed to be registered", name)
+	case AlertEventPanic:
+		return fmt.Sprintf("Operation cron %s has panicked", name)
+	case AlertEventOverdue:
+		window := ""
+		if a.Job != nil {
+			window = a.Job.SuccessWindow
+		}
+		return fmt.Sprintf("Operation cron %s has not succeeded within %s", name, window)
+	case AlertEventBroken:
+		return fmt.Sprintf("Operation cron %s has been paused after failing %d times in a row", name, a.ConsecutiveFailures)
+	case AlertEventHighQueueWait:
+		return fmt.Sprintf("Operation cron %s has waited too long for a worker", name)
+	default:
+		return fmt.Sprintf("Operation cron %s has %s event", name, a.Event)
+	}
+}
+
 type AlerterItf interface {
-	NotifyHighLatency(
-		ctx context.Context,
-		job *Job,
-		prev, next time.Time,
-		latency, maxLatency time.Duration,
-	)
+	Notify(ctx context.Context, alert *Alert)
 }
 
 func NewAlerter() *Alerter {
 	return &Alerter{}
 }
 
+// Alerter logs every alert as a warning.
 type Alerter struct{}
 
-func (a *Alerter) NotifyHighLatency(
-	ctx context.Context,
-	job *Job,
-	prev, next time.Time,
-	latency, maxLatency time.Duration,
-) {
-	logx.WRN(
-		ctx,
-		errorx.E(
-			"current run has not finished before a new run for next schedule is started",
-			errorx.Fields{
-				"prev_schedule":   prev.String(),
-				"next_schedule":   next.String(),
-				"current_latency": latency.String(),
-				"max_latency":     maxLatency.String(),
-			},
-		),
-		fmt.Sprintf("Operation cron %s has high latency", job.Name),
-	)
+func (a *Alerter) Notify(ctx context.Context, alert *Alert) {
+	fields := errorx.Fields{
+		"event":                alert.Event.String(),
+		"consecutive_failures": alert.ConsecutiveFailures,
+	}
+	for k, v := range alert.Fields {
+		fields[k] = v
+	}
+
+	var err error = errorx.E(alert.Message(), fields)
+	if alert.Err != nil {
+		err = errorx.E(alert.Err, fields)
+	}
+
+	logx.WRN(ctx, err, alert.Message())
+}
+
+// NewAlertGate creates a gate that lets the same alert of a job through once per cooldown.
+// Non-positive cooldown lets every alert through.
+func NewAlertGate(cooldown time.Duration) *AlertGate {
+	return &AlertGate{
+		mu:       sync.Mutex{},
+		cooldown: cooldown,
+		sent:     map[string]map[AlertEvent]time.Time{},
+	}
+}
+
+// AlertGate deduplicates alerts per job and event.
+type AlertGate struct {
+	mu       sync.Mutex
+	cooldown time.Duration
+	sent     map[string]map[AlertEvent]time.Time
+}
+
+// Allow returns true if the alert should be sent, and records it as sent.
+func (g *AlertGate) Allow(key string, event AlertEvent, now time.Time) bool {
+	if g.cooldown <= 0 {
+		return true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	events, ok := g.sent[key]
+	if !ok {
+		events = map[AlertEvent]time.Time{}
+		g.sent[key] = events
+	}
+	if last, ok := events[event]; ok && now.Sub(last) < g.cooldown {
+		return false
+	}
+
+	events[event] = now
+	return true
+}
+
+// Reset forgets every alert sent for the job,
+// so the next alert of the job is sent immediately.
+func (g *AlertGate) Reset(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.sent, key)
 }
@@ -0,0 +1,371 @@
+package alerter
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/cronx/storage"
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/rizalgowandy/gdk/pkg/jsonx"
+	"github.com/rizalgowandy/gdk/pkg/logx"
+	"github.com/rizalgowandy/gdk/pkg/netx"
+)
+
+// List of supported payload formats.
+const (
+	FormatJSON = "json"
+	FormatForm = "form"
+)
+
+// Default configuration for the webhook.
+var (
+	DefaultMaxRetries      = 3
+	DefaultRetryWait       = time.Second
+	DefaultSignatureHeader = "X-Cronx-Signature"
+	DefaultTimeout         = 10 * time.Second
+)
+
+// Payload is the data passed to the payload templates.
+// Without template, the payload itself is sent as JSON.
+type Payload struct {
+	Event               cronx.AlertEvent    `json:"event"`
+	Message             string              `json:"message"`
+	Time                time.Time           `json:"time"`
+	Host                string              `json:"host"`
+	Job                 *cronx.Job          `json:"job"`
+	History             *storage.History    `json:"history"`
+	Error               storage.ErrorDetail `json:"error"`
+	ConsecutiveFailures int64               `json:"consecutive_failures"`
+	Fields              errorx.Fields       `json:"fields"`
+}
+
+// Endpoint describes where and how an alert is delivered.
+type Endpoint struct {
+	// URL is the address receiving the alert.
+	URL string
+	// Format is either FormatJSON or FormatForm.
+	// Default is FormatJSON.
+	Format string
+	// Template renders the request body of FormatJSON using Payload as the data.
+	// Use the json function to escape values, e.g. {"text": {{json .Message}}}.
+	// Default is the payload itself marshalled as JSON.
+	Template string
+	// FormFields renders each value of FormatForm using Payload as the data.
+	FormFields map[string]string
+	// Headers is added to every request.
+	Headers map[string]string
+	// Secret signs the request body using HMAC-SHA256.
+	// The signature is sent as "sha256=<hex>" in the SignatureHeader.
+	Secret string
+	// SignatureHeader is the header name of the signature.
+	// Default is DefaultSignatureHeader.
+	SignatureHeader string
+	// Events routes only these events to the endpoint.
+	// Empty means every event.
+	Events []cronx.AlertEvent
+}
+
+// WebhookConfig describes the webhook alerter.
+type WebhookConfig struct {
+	// Endpoints receive the alerts.
+	Endpoints []Endpoint
+	// Client sends the requests.
+	// Default is a client with DefaultTimeout.
+	Client *http.Client
+	// MaxRetries is the number of retries on network error or 429 and 5xx response.
+	// Default is DefaultMaxRetries, negative value disables the retry.
+	MaxRetries int
+	// RetryWait is the wait before the first retry, multiplied by the attempt number afterward.
+	// Default is DefaultRetryWait.
+	RetryWait time.Duration
+}
+
+// NewWebhook creates an alerter that sends alerts to webhook endpoints.
+// Use it with cronx.WithAlerter.
+func NewWebhook(cfg WebhookConfig) (*Webhook, error) {
+	if len(cfg.Endpoints) == 0 {
+		return nil, errorx.E("endpoints cannot be empty")
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: DefaultTimeout}
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryWait <= 0 {
+		cfg.RetryWait = DefaultRetryWait
+	}
+
+	endpoints := make([]*endpoint, len(cfg.Endpoints))
+	for k, v := range cfg.Endpoints {
+		e, err := newEndpoint(v)
+		if err != nil {
+			return nil, errorx.E(err, errorx.Fields{"url": v.URL})
+		}
+		endpoints[k] = e
+	}
+
+	return &Webhook{
+		client:     cfg.Client,
+		maxRetries: cfg.MaxRetries,
+		retryWait:  cfg.RetryWait,
+		endpoints:  endpoints,
+		host:       netx.GetIPv4(),
+	}, nil
+}
+
+// Webhook sends alerts to webhook endpoints.
+type Webhook struct {
+	client     *http.Client
+	maxRetries int
+	retryWait  time.Duration
+	endpoints  []*endpoint
+	host       string
+}
+
+// Notify renders the alert right away, so the payload is a snapshot of the job at the time of the alert,
+// then sends it in the background, so the job is not blocked by the retries.
+func (w *Webhook) Notify(ctx context.Context, alert *cronx.Alert) {
+	reqs, errs := w.render(alert)
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := w.post(ctx, reqs, errs); err != nil {
+			logx.ERR(ctx, err, "send webhook alert must success")
+		}
+	}()
+}
+
+// Send sends the alert to every endpoint routed for the event.
+func (w *Webhook) Send(ctx context.Context, alert *cronx.Alert) error {
+	reqs, errs := w.render(alert)
+	return w.post(ctx, reqs, errs)
+}
+
+// request is the alert rendered for an endpoint.
+type request struct {
+	endpoint    *endpoint
+	event       cronx.AlertEvent
+	body        []byte
+	contentType string
+}
+
+// render renders the alert for every endpoint routed for the event.
+// An endpoint failing to render is skipped, and its error returned.
+func (w *Webhook) render(alert *cronx.Alert) ([]request, []error) {
+	payload := w.payload(alert)
+
+	var (
+		reqs []request
+		errs []error
+	)
+	for _, v := range w.endpoints {
+		if !v.accept(alert.Event) {
+			continue
+		}
+
+		body, contentType, err := v.render(payload)
+		if err != nil {
+			errs = append(errs, errorx.E(err, errorx.Fields{"url": v.URL, "event": alert.Event}))
+			continue
+		}
+		reqs = append(reqs, request{
+			endpoint:    v,
+			event:       alert.Event,
+			body:        body,
+			contentType: contentType,
+		})
+	}
+	return reqs, errs
+}
+
+// post sends the rendered requests, and returns the first error including the errors of rendering.
+func (w *Webhook) post(ctx context.Context, reqs []request, errs []error) error {
+	for _, v := range reqs {
+		if err := w.send(ctx, v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errorx.E(errs[0], errorx.Fields{"total_errors": len(errs)})
+	}
+
+	return nil
+}
+
+func (w *Webhook) payload(alert *cronx.Alert) *Payload {
+	return &Payload{
+		Event:               alert.Event,
+		Message:             alert.Message(),
+		Time:                alert.Time,
+		Host:                w.host,
+		Job:                 alert.Job,
+		History:             alert.History,
+		Error:               storage.NewErrorDetail(alert.Err),
+		ConsecutiveFailures: alert.ConsecutiveFailures,
+		Fields:              alert.Fields,
+	}
+}
+
+func (w *Webhook) send(ctx context.Context, req request) error {
+	fields := errorx.Fields{"url": req.endpoint.URL, "event": req.event}
+
+	for attempt := 0; ; attempt++ {
+		retry, err := w.do(ctx, req.endpoint, req.body, req.contentType)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.maxRetries {
+			return errorx.E(err, fields, errorx.Fields{"attempt": attempt + 1})
+		}
+
+		select {
+		case <-ctx.Done():
+			return errorx.E(ctx.Err(), fields)
+		case <-time.After(w.retryWait * time.Duration(attempt+1)):
+		}
+	}
+}
+
+// do sends a single request, and returns true if the request should be retried on error.
+func (w *Webhook) do(ctx context.Context, e *endpoint, body []byte, contentType string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, errorx.E(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+	if e.Secret != "" {
+		req.Header.Set(e.SignatureHeader, Sign(e.Secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, errorx.E(err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return false, nil
+	}
+
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+	return retry, errorx.E("unexpected webhook response", errorx.Fields{"status_code": resp.StatusCode})
+}
+
+// Sign returns the HMAC-SHA256 signature of the body as "sha256=<hex>".
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newEndpoint(cfg Endpoint) (*endpoint, error) {
+	if cfg.URL == "" {
+		return nil, errorx.E("url cannot be empty")
+	}
+	if cfg.Format == "" {
+		cfg.Format = FormatJSON
+	}
+	if cfg.SignatureHeader == "" {
+		cfg.SignatureHeader = DefaultSignatureHeader
+	}
+
+	e := &endpoint{
+		Endpoint: cfg,
+		body:     nil,
+		form:     map[string]*template.Template{},
+		events:   map[cronx.AlertEvent]bool{},
+	}
+	for _, v := range cfg.Events {
+		e.events[v] = true
+	}
+
+	switch cfg.Format {
+	case FormatJSON:
+		if cfg.Template == "" {
+			return e, nil
+		}
+		t, err := template.New(cfg.URL).Funcs(templateFuncs).Parse(cfg.Template)
+		if err != nil {
+			return nil, errorx.E(err)
+		}
+		e.body = t
+	case FormatForm:
+		for k, v := range cfg.FormFields {
+			t, err := template.New(k).Funcs(templateFuncs).Parse(v)
+			if err != nil {
+				return nil, errorx.E(err, errorx.Fields{"field": k})
+			}
+			e.form[k] = t
+		}
+	default:
+		return nil, errorx.E("unsupported format", errorx.Fields{"format": cfg.Format})
+	}
+
+	return e, nil
+}
+
+type endpoint struct {
+	Endpoint
+
+	body   *template.Template
+	form   map[string]*template.Template
+	events map[cronx.AlertEvent]bool
+}
+
+func (e *endpoint) accept(event cronx.AlertEvent) bool {
+	return len(e.events) == 0 || e.events[event]
+}
+
+func (e *endpoint) render(payload *Payload) ([]byte, string, error) {
+	if e.Format == FormatForm {
+		val := url.Values{}
+		for k, v := range e.form {
+			var b strings.Builder
+			if err := v.Execute(&b, payload); err != nil {
+				return nil, "", errorx.E(err, errorx.Fields{"field": k})
+			}
+			val.Set(k, b.String())
+		}
+		return []byte(val.Encode()), "application/x-www-form-urlencoded", nil
+	}
+
+	if e.body == nil {
+		b, err := jsonx.Marshal(payload)
+		if err != nil {
+			return nil, "", errorx.E(err)
+		}
+		return b, "application/json", nil
+	}
+
+	var b bytes.Buffer
+	if err := e.body.Execute(&b, payload); err != nil {
+		return nil, "", errorx.E(err)
+	}
+	return b.Bytes(), "application/json", nil
+}
+
+var templateFuncs = template.FuncMap{
+	// json escapes the value to be embedded inside a JSON template.
+	"json": func(v interface{}) (string, error) {
+		b, err := jsonx.Marshal(v)
+		return string(b), err
+	},
+}
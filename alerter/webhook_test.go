@@ -0,0 +1,241 @@
+package alerter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/gdk/pkg/jsonx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookRecorder struct {
+	mu       sync.Mutex
+	bodies   []string
+	headers  []http.Header
+	failures int
+}
+
+func (w *webhookRecorder) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	w.bodies = append(w.bodies, string(body))
+	w.headers = append(w.headers, r.Header.Clone())
+	if w.failures > 0 {
+		w.failures--
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+func newAlert(event cronx.AlertEvent) *cronx.Alert {
+	return &cronx.Alert{
+		Event:               event,
+		Job:                 &cronx.Job{Name: "payBill"},
+		Err:                 errors.New(`partner "api" is down`),
+		ConsecutiveFailures: 2,
+		Time:                time.Now(),
+	}
+}
+
+func TestNewWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     WebhookConfig
+		wantErr bool
+	}{
+		{
+			name:    "Empty endpoints",
+			cfg:     WebhookConfig{},
+			wantErr: true,
+		},
+		{
+			name:    "Empty url",
+			cfg:     WebhookConfig{Endpoints: []Endpoint{{}}},
+			wantErr: true,
+		},
+		{
+			name:    "Broken template",
+			cfg:     WebhookConfig{Endpoints: []Endpoint{{URL: "http://localhost", Template: "{{"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported format",
+			cfg:     WebhookConfig{Endpoints: []Endpoint{{URL: "http://localhost", Format: "xml"}}},
+			wantErr: true,
+		},
+		{
+			name:    "Success",
+			cfg:     WebhookConfig{Endpoints: []Endpoint{{URL: "http://localhost"}}},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewWebhook(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewWebhook() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestWebhook_SendJSON(t *testing.T) {
+	recorder := &webhookRecorder{}
+	srv := httptest.NewServer(recorder)
+	defer srv.Close()
+
+	w, err := NewWebhook(WebhookConfig{
+		Endpoints: []Endpoint{
+			{
+				URL:      srv.URL,
+				Template: `{"text": {{json .Message}}, "error": {{json .Error.Err}}, "failures": {{.ConsecutiveFailures}}}`,
+				Headers:  map[string]string{"X-Team": "billing"},
+				Secret:   "secret",
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Send(context.Background(), newAlert(cronx.AlertEventFailure)))
+
+	require.Len(t, recorder.bodies, 1)
+	var got map[string]interface{}
+	require.NoError(t, jsonx.Unmarshal([]byte(recorder.bodies[0]), &got))
+	assert.Equal(t, "Operation cron payBill has failed", got["text"])
+	assert.Equal(t, `partner "api" is down`, got["error"])
+	assert.EqualValues(t, 2, got["failures"])
+	assert.Equal(t, "application/json", recorder.headers[0].Get("Content-Type"))
+	assert.Equal(t, "billing", recorder.headers[0].Get("X-Team"))
+	assert.Equal(t, Sign("secret", []byte(recorder.bodies[0])), recorder.headers[0].Get(DefaultSignatureHeader))
+}
+
+func TestWebhook_SendDefaultPayload(t *testing.T) {
+	recorder := &webhookRecorder{}
+	srv := httptest.NewServer(recorder)
+	defer srv.Close()
+
+	w, err := NewWebhook(WebhookConfig{Endpoints: []Endpoint{{URL: srv.URL}}})
+	require.NoError(t, err)
+	require.NoError(t, w.Send(context.Background(), newAlert(cronx.AlertEventPanic)))
+
+	require.Len(t, recorder.bodies, 1)
+	var got Payload
+	require.NoError(t, jsonx.Unmarshal([]byte(recorder.bodies[0]), &got))
+	assert.Equal(t, cronx.AlertEventPanic, got.Event)
+	assert.Equal(t, "payBill", got.Job.Name)
+	assert.Empty(t, recorder.headers[0].Get(DefaultSignatureHeader))
+}
+
+func TestWebhook_SendForm(t *testing.T) {
+	recorder := &webhookRecorder{}
+	srv := httptest.NewServer(recorder)
+	defer srv.Close()
+
+	w, err := NewWebhook(WebhookConfig{
+		Endpoints: []Endpoint{
+			{
+				URL:        srv.URL,
+				Format:     FormatForm,
+				FormFields: map[string]string{"summary": "{{.Job.Name}} {{.Event}}"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Send(context.Background(), newAlert(cronx.AlertEventTimeout)))
+
+	require.Len(t, recorder.bodies, 1)
+	assert.Equal(t, "summary=payBill+TIMEOUT", recorder.bodies[0])
+	assert.Equal(t, "application/x-www-form-urlencoded", recorder.headers[0].Get("Content-Type"))
+}
+
+func TestWebhook_SendRouting(t *testing.T) {
+	pager := &webhookRecorder{}
+	pagerSrv := httptest.NewServer(pager)
+	defer pagerSrv.Close()
+
+	chat := &webhookRecorder{}
+	chatSrv := httptest.NewServer(chat)
+	defer chatSrv.Close()
+
+	w, err := NewWebhook(WebhookConfig{
+		Endpoints: []Endpoint{
+			{URL: pagerSrv.URL, Events: []cronx.AlertEvent{cronx.AlertEventConsecutiveFailure}},
+			{URL: chatSrv.URL},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Send(context.Background(), newAlert(cronx.AlertEventFailure)))
+	require.NoError(t, w.Send(context.Background(), newAlert(cronx.AlertEventConsecutiveFailure)))
+
+	assert.Len(t, pager.bodies, 1)
+	assert.Len(t, chat.bodies, 2)
+}
+
+func TestWebhook_SendRetry(t *testing.T) {
+	recorder := &webhookRecorder{failures: 2}
+	srv := httptest.NewServer(recorder)
+	defer srv.Close()
+
+	w, err := NewWebhook(WebhookConfig{
+		Endpoints:  []Endpoint{{URL: srv.URL}},
+		MaxRetries: 2,
+		RetryWait:  time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Send(context.Background(), newAlert(cronx.AlertEventFailure)))
+	assert.Len(t, recorder.bodies, 3)
+
+	recorder = &webhookRecorder{failures: 5}
+	srv2 := httptest.NewServer(recorder)
+	defer srv2.Close()
+
+	w, err = NewWebhook(WebhookConfig{
+		Endpoints:  []Endpoint{{URL: srv2.URL}},
+		MaxRetries: -1,
+	})
+	require.NoError(t, err)
+	require.Error(t, w.Send(context.Background(), newAlert(cronx.AlertEventFailure)))
+	assert.Len(t, recorder.bodies, 1)
+}
+
+func TestWebhook_Notify(t *testing.T) {
+	recorder := &webhookRecorder{}
+	srv := httptest.NewServer(recorder)
+	defer srv.Close()
+
+	w, err := NewWebhook(WebhookConfig{Endpoints: []Endpoint{{URL: srv.URL}}})
+	require.NoError(t, err)
+
+	alert := newAlert(cronx.AlertEventFailure)
+	alert.Job.Error = "partner is down"
+	w.Notify(context.Background(), alert)
+
+	// The job keeps changing once notified, the alert is a snapshot at the time of the alert.
+	alert.Job.Error = "partner is up"
+
+	require.Eventually(t, func() bool {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		return len(recorder.bodies) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	var got Payload
+	require.NoError(t, jsonx.Unmarshal([]byte(recorder.bodies[0]), &got))
+	assert.Equal(t, "partner is down", got.Job.Error)
+}
@@ -0,0 +1,163 @@
+package cronx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/rizalgowandy/gdk/pkg/tags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type alertRecorder struct {
+	alerts []Alert
+}
+
+func (a *alertRecorder) Notify(_ context.Context, alert *Alert) {
+	a.alerts = append(a.alerts, *alert)
+}
+
+func (a *alertRecorder) events() []AlertEvent {
+	res := make([]AlertEvent, len(a.alerts))
+	for k, v := range a.alerts {
+		res[k] = v.Event
+	}
+	return res
+}
+
+func TestAlertGate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	gate := NewAlertGate(time.Minute)
+	assert.True(t, gate.Allow("job", AlertEventFailure, now))
+	assert.False(t, gate.Allow("job", AlertEventFailure, now.Add(time.Second)))
+	assert.True(t, gate.Allow("job", AlertEventTimeout, now.Add(time.Second)))
+	assert.True(t, gate.Allow("other", AlertEventFailure, now.Add(time.Second)))
+	assert.True(t, gate.Allow("job", AlertEventFailure, now.Add(time.Minute)))
+
+	gate.Reset("job")
+	assert.True(t, gate.Allow("job", AlertEventFailure, now.Add(time.Minute)))
+
+	gate = NewAlertGate(0)
+	assert.True(t, gate.Allow("job", AlertEventFailure, now))
+	assert.True(t, gate.Allow("job", AlertEventFailure, now))
+}
+
+func TestJob_RunAlerts(t *testing.T) {
+	t.Parallel()
+
+	alerter := &alertRecorder{}
+	manager := NewManager(
+		WithAutoStartDisabled(),
+		WithAlerter(alerter),
+		WithAlertFailureThreshold(3),
+	)
+
+	var runErr error
+	j := NewJob(manager, Func(func(context.Context) error { return runErr }), 1, 1)
+
+	runErr = errors.New("error")
+	for i := 0; i < 4; i++ {
+		j.Run()
+	}
+	assert.Equal(t, int64(4), j.ConsecutiveFailures)
+	assert.Equal(t, []AlertEvent{AlertEventFailure, AlertEventConsecutiveFailure}, alerter.events())
+
+	runErr = nil
+	j.Run()
+	j.Run()
+	assert.Zero(t, j.ConsecutiveFailures)
+	require.Len(t, alerter.alerts, 3)
+	assert.Equal(t, AlertEventRecovery, alerter.alerts[2].Event)
+	assert.Equal(t, int64(4), alerter.alerts[2].ConsecutiveFailures)
+
+	// Cooldown is reset after recovery.
+	runErr = errorx.E("panic", errorx.Fields{tags.Panic: "boom"})
+	j.Run()
+	runErr = context.DeadlineExceeded
+	j.Run()
+	runErr = errorx.E("expected", errorx.MetricStatusExpectedErr)
+	j.Run()
+	assert.Equal(t, []AlertEvent{
+		AlertEventFailure,
+		AlertEventConsecutiveFailure,
+		AlertEventRecovery,
+		AlertEventPanic,
+		AlertEventTimeout,
+		AlertEventConsecutiveFailure,
+	}, alerter.events())
+}
+
+func TestJob_RunAlertsTimeout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want AlertEvent
+	}{
+		{
+			name: "Deadline exceeded",
+			err:  context.DeadlineExceeded,
+			want: AlertEventTimeout,
+		},
+		{
+			name: "Deadline exceeded wrapped by errorx",
+			err:  errorx.E(context.DeadlineExceeded, errorx.CodeGateway),
+			want: AlertEventTimeout,
+		},
+		{
+			name: "Deadline exceeded wrapped by errorx and fmt",
+			err:  errorx.E(fmt.Errorf("call partner: %w", errorx.E(context.DeadlineExceeded))),
+			want: AlertEventTimeout,
+		},
+		{
+			name: "Other error wrapped by errorx",
+			err:  errorx.E(errors.New("partner is down")),
+			want: AlertEventFailure,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			alerter := &alertRecorder{}
+			manager := NewManager(WithAutoStartDisabled(), WithAlerter(alerter))
+			j := NewJob(manager, Func(func(context.Context) error { return tt.err }), 1, 1)
+
+			j.Run()
+			assert.Equal(t, []AlertEvent{tt.want}, alerter.events())
+		})
+	}
+}
+
+func TestManager_ScheduleAlertsDown(t *testing.T) {
+	t.Parallel()
+
+	alerter := &alertRecorder{}
+	manager := NewManager(WithAutoStartDisabled(), WithAlerter(alerter))
+
+	err := manager.Schedule("clearly a broken spec", Func(func(context.Context) error { return nil }))
+	require.Error(t, err)
+	require.Len(t, alerter.alerts, 1)
+	assert.Equal(t, AlertEventDown, alerter.alerts[0].Event)
+	assert.Equal(t, err, alerter.alerts[0].Err)
+}
+
+func TestAlert_Message(t *testing.T) {
+	t.Parallel()
+
+	alert := &Alert{
+		Event:               AlertEventConsecutiveFailure,
+		Job:                 &Job{Name: "payBill"},
+		ConsecutiveFailures: 3,
+	}
+	assert.Equal(t, "Operation cron payBill has failed 3 times in a row", alert.Message())
+
+	NewAlerter().Notify(context.Background(), alert)
+}
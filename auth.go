@@ -0,0 +1,227 @@
+package cronx
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Role describes what an authenticated user can do on the server.
+type Role string
+
+func (r Role) String() string {
+	return string(r)
+}
+
+const (
+	// RoleViewer can only see the jobs, histories, and metrics.
+	RoleViewer Role = "viewer"
+	// RoleOperator can see everything and control the jobs, e.g. resetting the circuit breaker.
+	RoleOperator Role = "operator"
+)
+
+// contextKeyRole is the key of the authenticated role on the request context.
+const contextKeyRole = "cronx_role"
+
+// contextKeyOperator is the key of who has sent the request on the request context.
+const contextKeyOperator = "cronx_operator"
+
+// allows returns true if the role has the permission of the required role.
+func (r Role) allows(required Role) bool {
+	switch r {
+	case RoleOperator:
+		return true
+	case RoleViewer:
+		return required == RoleViewer
+	default:
+		return false
+	}
+}
+
+// AuthenticatorItf authenticates a request to the server.
+type AuthenticatorItf interface {
+	// Authenticate returns the role of the request, or an empty role if the request is not authenticated.
+	Authenticate(r *http.Request) Role
+}
+
+// challengerItf is an optional interface of an authenticator
+// to tell the client how to authenticate, e.g. a browser prompts for basic auth.
+type challengerItf interface {
+	Challenge() string
+}
+
+// identifierItf is an optional interface of an authenticator
+// to tell who has sent the request, e.g. the username, recorded on the actions of an operator.
+type identifierItf interface {
+	Identify(r *http.Request) string
+}
+
+// BasicAuthUser is a user allowed to access the server using basic auth.
+type BasicAuthUser struct {
+	Username string
+	Password string
+	Role     Role
+}
+
+// NewBasicAuth creates an authenticator using basic auth.
+func NewBasicAuth(users ...BasicAuthUser) *BasicAuth {
+	return &BasicAuth{users: users}
+}
+
+// BasicAuth authenticates a request using the username and password.
+type BasicAuth struct {
+	users []BasicAuthUser
+}
+
+// Authenticate returns the role of the user matching the username and password.
+func (b *BasicAuth) Authenticate(r *http.Request) Role {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return ""
+	}
+
+	var role Role
+	for _, v := range b.users {
+		// Compare every user, so the response time does not leak which user exists.
+		matchUsername := subtle.ConstantTimeCompare([]byte(username), []byte(v.Username))
+		matchPassword := subtle.ConstantTimeCompare([]byte(password), []byte(v.Password))
+		if matchUsername&matchPassword == 1 {
+			role = v.Role
+		}
+	}
+	return role
+}
+
+// Identify returns the username of the request.
+func (b *BasicAuth) Identify(r *http.Request) string {
+	username, _, _ := r.BasicAuth()
+	return username
+}
+
+// Challenge asks the browser to prompt for the username and password.
+func (b *BasicAuth) Challenge() string {
+	return `Basic realm="cronx"`
+}
+
+// NewTokenAuth creates an authenticator using static bearer tokens and their role.
+func NewTokenAuth(tokens map[string]Role) *TokenAuth {
+	return &TokenAuth{tokens: tokens}
+}
+
+// TokenAuth authenticates a request using a static bearer token.
+type TokenAuth struct {
+	tokens map[string]Role
+}
+
+// Authenticate returns the role of the bearer token.
+func (t *TokenAuth) Authenticate(r *http.Request) Role {
+	token := bearerToken(r)
+	if token == "" {
+		return ""
+	}
+
+	var role Role
+	for k, v := range t.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(k)) == 1 {
+			role = v
+		}
+	}
+	return role
+}
+
+// Verifier verifies a bearer token, e.g. a JWT or an OIDC token, and returns its role.
+type Verifier func(ctx context.Context, token string) (Role, error)
+
+// NewVerifierAuth creates an authenticator that verifies the bearer token using the verifier.
+func NewVerifierAuth(verify Verifier) *VerifierAuth {
+	return &VerifierAuth{verify: verify}
+}
+
+// VerifierAuth authenticates a request using a verifier of the bearer token.
+type VerifierAuth struct {
+	verify Verifier
+}
+
+// Authenticate returns the role returned by the verifier, or an empty role if the verification fails.
+func (v *VerifierAuth) Authenticate(r *http.Request) Role {
+	token := bearerToken(r)
+	if token == "" {
+		return ""
+	}
+
+	role, err := v.verify(r.Context(), token)
+	if err != nil {
+		return ""
+	}
+	return role
+}
+
+// bearerToken returns the token of the Authorization header, empty if none.
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get(echo.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// authenticate is a middleware that rejects the request not authenticated by any of the authenticators.
+// Without authenticator, every request is allowed as an operator.
+func authenticate(authenticators []AuthenticatorItf) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if len(authenticators) == 0 {
+				ctx.Set(contextKeyRole, RoleOperator)
+				ctx.Set(contextKeyOperator, identify(ctx, nil, RoleOperator))
+				return next(ctx)
+			}
+
+			for _, v := range authenticators {
+				if role := v.Authenticate(ctx.Request()); role != "" {
+					ctx.Set(contextKeyRole, role)
+					ctx.Set(contextKeyOperator, identify(ctx, v, role))
+					return next(ctx)
+				}
+			}
+
+			for _, v := range authenticators {
+				if c, ok := v.(challengerItf); ok {
+					ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, c.Challenge())
+					break
+				}
+			}
+			return ctx.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "unauthenticated",
+			})
+		}
+	}
+}
+
+// identify returns who has sent the request,
+// either the identity given by the authenticator or the role and the IP address of the client.
+func identify(ctx echo.Context, authenticator AuthenticatorItf, role Role) string {
+	if i, ok := authenticator.(identifierItf); ok {
+		if identity := i.Identify(ctx.Request()); identity != "" {
+			return identity
+		}
+	}
+	return role.String() + "@" + ctx.RealIP()
+}
+
+// requireRole is a middleware that rejects the request without the permission of the role.
+func requireRole(required Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			role, _ := ctx.Get(contextKeyRole).(Role)
+			if !role.allows(required) {
+				return ctx.JSON(http.StatusForbidden, map[string]string{
+					"error": "role " + required.String() + " is required",
+				})
+			}
+			return next(ctx)
+		}
+	}
+}
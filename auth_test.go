@@ -0,0 +1,218 @@
+package cronx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRole_allows(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, RoleOperator.allows(RoleOperator))
+	assert.True(t, RoleOperator.allows(RoleViewer))
+	assert.True(t, RoleViewer.allows(RoleViewer))
+	assert.False(t, RoleViewer.allows(RoleOperator))
+	assert.False(t, Role("").allows(RoleViewer))
+}
+
+func TestBasicAuth_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	auth := NewBasicAuth(
+		BasicAuthUser{Username: "viewer", Password: "secret", Role: RoleViewer},
+		BasicAuthUser{Username: "admin", Password: "secret", Role: RoleOperator},
+	)
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		want     Role
+	}{
+		{
+			name:     "Viewer",
+			username: "viewer",
+			password: "secret",
+			want:     RoleViewer,
+		},
+		{
+			name:     "Operator",
+			username: "admin",
+			password: "secret",
+			want:     RoleOperator,
+		},
+		{
+			name:     "Wrong password",
+			username: "admin",
+			password: "wrong",
+			want:     "",
+		},
+		{
+			name:     "Unknown user",
+			username: "guest",
+			password: "secret",
+			want:     "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+			req.SetBasicAuth(tt.username, tt.password)
+			assert.Equal(t, tt.want, auth.Authenticate(req))
+		})
+	}
+
+	assert.Equal(t, Role(""), auth.Authenticate(httptest.NewRequest(http.MethodGet, "/jobs", nil)))
+}
+
+func TestTokenAuth_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	auth := NewTokenAuth(map[string]Role{"view-token": RoleViewer, "ops-token": RoleOperator})
+
+	tests := []struct {
+		name   string
+		header string
+		want   Role
+	}{
+		{
+			name:   "Viewer",
+			header: "Bearer view-token",
+			want:   RoleViewer,
+		},
+		{
+			name:   "Operator",
+			header: "bearer ops-token",
+			want:   RoleOperator,
+		},
+		{
+			name:   "Unknown token",
+			header: "Bearer unknown",
+			want:   "",
+		},
+		{
+			name:   "Other scheme",
+			header: "Basic ops-token",
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+			req.Header.Set("Authorization", tt.header)
+			assert.Equal(t, tt.want, auth.Authenticate(req))
+		})
+	}
+}
+
+func TestVerifierAuth_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	auth := NewVerifierAuth(func(_ context.Context, token string) (Role, error) {
+		if token != "valid.jwt" {
+			return RoleOperator, errors.New("invalid signature")
+		}
+		return RoleOperator, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+	req.Header.Set("Authorization", "Bearer valid.jwt")
+	assert.Equal(t, RoleOperator, auth.Authenticate(req))
+
+	req.Header.Set("Authorization", "Bearer forged.jwt")
+	assert.Equal(t, Role(""), auth.Authenticate(req))
+}
+
+func TestNewHandlerWithAuth(t *testing.T) {
+	manager := NewManager(WithAutoStartDisabled())
+	_ = manager.Schedule("@every 5m", &invoiceJob{})
+	resetPath := "/api/jobs/" + strconv.Itoa(int(manager.GetEntries()[0].ID)) + "/breaker/reset"
+
+	handler := NewHandler(
+		manager,
+		WithAuth(
+			NewBasicAuth(BasicAuthUser{Username: "admin", Password: "secret", Role: RoleOperator}),
+			NewTokenAuth(map[string]Role{"view-token": RoleViewer}),
+		),
+		WithCORS("https://admin.example.com"),
+	)
+
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		header    string
+		basicAuth bool
+		expect    int
+	}{
+		{
+			name:   "Unauthenticated",
+			method: http.MethodGet,
+			target: "/jobs",
+			expect: http.StatusUnauthorized,
+		},
+		{
+			name:   "Viewer can see jobs",
+			method: http.MethodGet,
+			target: "/api/jobs",
+			header: "Bearer view-token",
+			expect: http.StatusOK,
+		},
+		{
+			name:   "Viewer cannot reset breaker",
+			method: http.MethodPost,
+			target: resetPath,
+			header: "Bearer view-token",
+			expect: http.StatusForbidden,
+		},
+		{
+			name:      "Operator can reset breaker",
+			method:    http.MethodPost,
+			target:    resetPath,
+			basicAuth: true,
+			expect:    http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set("Origin", "https://admin.example.com")
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.basicAuth {
+				req.SetBasicAuth("admin", "secret")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expect, rec.Code)
+			assert.Equal(t, "https://admin.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+			if tt.expect == http.StatusUnauthorized {
+				assert.Equal(t, `Basic realm="cronx"`, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestNewHandlerWithoutCORS(t *testing.T) {
+	handler := NewHandler(NewManager(WithAutoStartDisabled()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/jobs", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+}
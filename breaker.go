@@ -0,0 +1,103 @@
+package cronx
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/robfig/cron/v3"
+)
+
+// BreakerState describes the state of the job circuit breaker.
+type BreakerState string
+
+func (b BreakerState) String() string {
+	return string(b)
+}
+
+const (
+	// BreakerStateClosed describes that the job runs on schedule.
+	BreakerStateClosed BreakerState = "CLOSED"
+	// BreakerStateOpen describes that the job has failed too many times in a row,
+	// and the runs are skipped until the cooldown has passed.
+	BreakerStateOpen BreakerState = "OPEN"
+	// BreakerStateHalfOpen describes that the cooldown has passed,
+	// and the next run decides whether the breaker is closed or opened again.
+	BreakerStateHalfOpen BreakerState = "HALF_OPEN"
+)
+
+// allowRun returns false if the breaker is open and the run must be skipped.
+// Once the cooldown has passed, the breaker is half-opened to let a single run through.
+func (j *Job) allowRun(now time.Time) bool {
+	j.breakerMu.Lock()
+	defer j.breakerMu.Unlock()
+
+	if j.Breaker != BreakerStateOpen {
+		return true
+	}
+	if now.Before(j.BreakerRetryAt) {
+		return false
+	}
+
+	j.Breaker = BreakerStateHalfOpen
+	return true
+}
+
+// updateBreaker updates the breaker based on the run outcome.
+// It returns true if the breaker has just been opened.
+func (j *Job) updateBreaker(err error, now time.Time) bool {
+	if j.manager.breakerThreshold <= 0 {
+		return false
+	}
+
+	j.breakerMu.Lock()
+	defer j.breakerMu.Unlock()
+
+	if err == nil {
+		j.Breaker = BreakerStateClosed
+		j.BreakerRetryAt = time.Time{}
+		return false
+	}
+	if j.Breaker != BreakerStateHalfOpen && j.ConsecutiveFailures < j.manager.breakerThreshold {
+		return false
+	}
+
+	j.Breaker = BreakerStateOpen
+	j.BreakerRetryAt = now.Add(j.manager.breakerCooldown)
+	return true
+}
+
+// breakerRetryAt returns the time the open breaker lets a run through again.
+func (j *Job) breakerRetryAt() time.Time {
+	j.breakerMu.Lock()
+	defer j.breakerMu.Unlock()
+
+	return j.BreakerRetryAt
+}
+
+// ResetBreaker closes the breaker and clears the consecutive failures,
+// so the next run is not skipped and a single failure does not open the breaker again.
+func (j *Job) ResetBreaker() {
+	j.breakerMu.Lock()
+	defer j.breakerMu.Unlock()
+
+	j.Breaker = BreakerStateClosed
+	j.BreakerRetryAt = time.Time{}
+	j.ConsecutiveFailures = 0
+	if atomic.CompareAndSwapUint32(&j.status, statusBroken, statusUp) {
+		j.UpdateStatus()
+	}
+}
+
+// ResetBreaker closes the breaker of a specific job.
+// Get EntryID from the list job entries manager.GetEntries().
+func (m *Manager) ResetBreaker(id cron.EntryID) error {
+	entry := m.commander.Entry(id)
+	job, ok := entry.Job.(*Job)
+	if !entry.Valid() || !ok {
+		return errorx.E("job not found", errorx.CodeNotFound, errorx.Fields{"entry_id": id})
+	}
+
+	job.ResetBreaker()
+	return nil
+}
@@ -0,0 +1,107 @@
+package cronx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJob_RunCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	alerter := &alertRecorder{}
+	recorder := &historyRecorder{}
+	manager := NewManager(
+		WithAutoStartDisabled(),
+		WithAlerter(alerter),
+		WithStorage(recorder),
+		WithCircuitBreaker(2, time.Hour),
+	)
+
+	runs := 0
+	runErr := errors.New("partner is down")
+	require.NoError(t, manager.ScheduleFunc("@every 5s", "payBill", func(context.Context) error {
+		runs++
+		return runErr
+	}))
+	j := manager.GetEntries()[0].Job.(*Job)
+
+	// The breaker is opened after failing 2 times in a row.
+	j.Run()
+	assert.Equal(t, BreakerStateClosed, j.Breaker)
+	j.Run()
+	assert.Equal(t, BreakerStateOpen, j.Breaker)
+	assert.False(t, j.BreakerRetryAt.IsZero())
+	assert.Contains(t, alerter.events(), AlertEventBroken)
+
+	// Runs are skipped while the breaker is open, and recorded as broken.
+	events, unsubscribe := manager.Subscribe()
+	j.Run()
+	j.Run()
+	unsubscribe()
+	assert.Equal(t, 2, runs)
+	assert.Equal(t, StatusCodeBroken, j.Status)
+	require.Len(t, recorder.written, 4)
+	for _, history := range recorder.written[2:] {
+		assert.Equal(t, StatusCodeBroken.String(), history.Status)
+		assert.Equal(t, int64(statusBroken), history.StatusCode)
+		assert.Equal(t, history.StartedAt, history.FinishedAt)
+		assert.Equal(t, "circuit breaker is open", history.Error.Err)
+	}
+
+	var got []Event
+	for len(events) > 0 {
+		got = append(got, <-events)
+	}
+	require.Len(t, got, 3)
+	assert.Equal(t, EventStatusChanged, got[0].Type)
+	assert.Equal(t, StatusCodeBroken, got[0].Status)
+	assert.Equal(t, EventRunFinished, got[1].Type)
+	assert.Equal(t, int64(3), got[1].HistoryID)
+	assert.Equal(t, EventRunFinished, got[2].Type)
+	assert.Equal(t, int64(4), got[2].HistoryID)
+
+	// A failed run after the cooldown opens the breaker again.
+	j.BreakerRetryAt = time.Now().Add(-time.Second)
+	j.Run()
+	assert.Equal(t, 3, runs)
+	assert.Equal(t, BreakerStateOpen, j.Breaker)
+
+	// A successful run after the cooldown closes the breaker.
+	j.BreakerRetryAt = time.Now().Add(-time.Second)
+	runErr = nil
+	j.Run()
+	assert.Equal(t, 4, runs)
+	assert.Equal(t, BreakerStateClosed, j.Breaker)
+	assert.Equal(t, StatusCodeSuccess, j.Status)
+}
+
+func TestManager_ResetBreaker(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(WithAutoStartDisabled(), WithCircuitBreaker(1, time.Hour))
+	require.NoError(t, manager.ScheduleFunc("@every 5s", "payBill", func(context.Context) error {
+		return errors.New("partner is down")
+	}))
+	entry := manager.GetEntries()[0]
+	j := entry.Job.(*Job)
+
+	j.Run()
+	j.Run()
+	assert.Equal(t, BreakerStateOpen, j.Breaker)
+	assert.Equal(t, StatusCodeBroken, j.Status)
+
+	require.NoError(t, manager.ResetBreaker(entry.ID))
+	assert.Equal(t, BreakerStateClosed, j.Breaker)
+	assert.Equal(t, StatusCodeUp, j.Status)
+	assert.Zero(t, j.ConsecutiveFailures)
+
+	err := manager.ResetBreaker(entry.ID + 1)
+	require.Error(t, err)
+	assert.True(t, errorx.Is(err, errorx.CodeNotFound))
+}
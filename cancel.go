@@ -0,0 +1,100 @@
+package cronx
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+)
+
+// runCanceller cancels the context of the current run of a job.
+type runCanceller struct {
+	mu sync.Mutex
+	// cancel cancels the context of the current run, nil if the job is not running.
+	cancel context.CancelCauseFunc
+	// cancelledBy is who has cancelled the current run, empty if not cancelled.
+	cancelledBy string
+}
+
+// start returns the context of a new run that can be cancelled.
+func (r *runCanceller) start(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cancel = cancel
+	r.cancelledBy = ""
+	return ctx
+}
+
+// finish releases the context of the current run,
+// and returns who has cancelled the run, empty if not cancelled.
+func (r *runCanceller) finish() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cancel != nil {
+		r.cancel(nil)
+		r.cancel = nil
+	}
+	return r.cancelledBy
+}
+
+// cancelRun cancels the context of the current run.
+// It returns false if the job is not running or the run has already been cancelled.
+func (r *runCanceller) cancelRun(err error, operator string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cancel == nil || r.cancelledBy != "" {
+		return false
+	}
+
+	r.cancelledBy = operator
+	r.cancel(err)
+	return true
+}
+
+// NewCancelError creates the error of a run cancelled by the operator.
+// The error is recorded as the error of the cancelled run.
+func NewCancelError(job *Job, operator string) error {
+	return errorx.E(
+		"run is cancelled by "+operator,
+		errorx.Op(job.Name),
+		errorx.CodeConflict,
+		errorx.Fields{"cancelled_by": operator},
+	)
+}
+
+// Cancel cancels the context of the current run, recorded as cancelled by the operator.
+// It returns false if the job is not running.
+func (j *Job) Cancel(operator string) bool {
+	return j.canceller.cancelRun(NewCancelError(j, operator), operator)
+}
+
+// Cancel cancels the current run of the jobs matching the key, recorded as cancelled by the operator.
+// The key is either the key of a job, see Job.Key, or the name of a job to cancel every wave of the job.
+// The job must stop on the cancellation of its context, otherwise the run is only recorded as cancelled once finished.
+func (m *Manager) Cancel(key, operator string) error {
+	var found, cancelled bool
+	for _, entry := range m.commander.Entries() {
+		job, ok := entry.Job.(*Job)
+		if !ok || (job.Key() != key && job.Name != key) {
+			continue
+		}
+
+		found = true
+		if job.Cancel(operator) {
+			cancelled = true
+		}
+	}
+
+	if !found {
+		return errorx.E("job not found", errorx.CodeNotFound, errorx.Fields{"key": key})
+	}
+	if !cancelled {
+		return errorx.E("job is not running", errorx.CodeConflict, errorx.Fields{"key": key})
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package cronx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingJob runs until its context is cancelled.
+func blockingJob(started chan<- struct{}) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+}
+
+func TestManager_Cancel(t *testing.T) {
+	t.Parallel()
+
+	alerter := &alertRecorder{}
+	recorder := &historyRecorder{}
+	manager := NewManager(
+		WithAutoStartDisabled(),
+		WithAlerter(alerter),
+		WithStorage(recorder),
+		WithCircuitBreaker(1, time.Hour),
+	)
+
+	started := make(chan struct{}, 1)
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", blockingJob(started)))
+	j := manager.GetEntries()[0].Job.(*Job)
+
+	require.Error(t, manager.Cancel("unknown", "admin"))
+	require.Error(t, manager.Cancel("payBill", "admin"), "job is not running")
+
+	done := make(chan struct{})
+	go func() {
+		j.Run()
+		close(done)
+	}()
+	<-started
+	require.NoError(t, manager.Cancel("payBill", "admin"))
+	<-done
+
+	assert.Equal(t, StatusCodeCancelled, j.Status)
+	assert.Contains(t, j.Error, "cancelled by admin")
+	require.Len(t, recorder.updated, 1)
+	assert.Equal(t, StatusCodeCancelled.String(), recorder.updated[0].Status)
+	assert.Equal(t, "admin", recorder.updated[0].Metadata.CancelledBy)
+
+	// A cancelled run is neither alerted nor counted by the circuit breaker.
+	assert.Empty(t, alerter.events())
+	assert.Equal(t, BreakerStateClosed, j.Breaker)
+	assert.Zero(t, j.ConsecutiveFailures)
+
+	// The next run is not cancelled.
+	j.inner = Func(func(context.Context) error { return nil })
+	j.Run()
+	assert.Equal(t, StatusCodeSuccess, j.Status)
+	assert.Empty(t, recorder.updated[1].Metadata.CancelledBy)
+}
+
+func TestServerController_APICancel(t *testing.T) {
+	manager := NewManager(WithAutoStartDisabled())
+	started := make(chan struct{}, 1)
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", blockingJob(started)))
+	j := manager.GetEntries()[0].Job.(*Job)
+	cancelPath := "/api/jobs/" + strconv.Itoa(int(j.EntryID)) + "/cancel"
+
+	handler := NewHandler(
+		manager,
+		WithAuth(
+			NewBasicAuth(BasicAuthUser{Username: "admin", Password: "secret", Role: RoleOperator}),
+			NewTokenAuth(map[string]Role{"view-token": RoleViewer}),
+		),
+	)
+	post := func(target string, auth func(r *http.Request)) int {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		auth(req)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	asAdmin := func(r *http.Request) { r.SetBasicAuth("admin", "secret") }
+
+	assert.Equal(t, http.StatusConflict, post(cancelPath, asAdmin))
+	assert.Equal(t, http.StatusNotFound, post("/api/jobs/100/cancel", asAdmin))
+	assert.Equal(t, http.StatusBadRequest, post("/api/jobs/abc/cancel", asAdmin))
+
+	done := make(chan struct{})
+	go func() {
+		j.Run()
+		close(done)
+	}()
+	<-started
+
+	viewer := func(r *http.Request) { r.Header.Set("Authorization", "Bearer view-token") }
+	assert.Equal(t, http.StatusForbidden, post(cancelPath, viewer))
+	assert.Equal(t, http.StatusAccepted, post(cancelPath, asAdmin))
+	<-done
+
+	assert.Equal(t, StatusCodeCancelled, j.Status)
+	assert.Contains(t, j.Error, "cancelled by admin")
+}
@@ -0,0 +1,263 @@
+// Package client is a typed client of the HTTP API served by cronx.NewServer, cronx.NewSideCarServer, and cronx.NewHandler.
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/cronx/storage"
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/robfig/cron/v3"
+)
+
+// Option configures the client.
+type Option func(*Client)
+
+// WithHTTPClient sends the requests using the http client, e.g. to set a timeout.
+// The timeout also applies to Subscribe, so prefer cancelling the context instead.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithBasicAuth authenticates every request using the username and password, see cronx.NewBasicAuth.
+func WithBasicAuth(username, password string) Option {
+	return func(c *Client) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithToken authenticates every request using the bearer token, see cronx.NewTokenAuth.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// New creates a client of the server at the base URL, including the prefix of cronx.WithPrefix if any,
+// e.g. http://localhost:9001/admin/cron.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, errorx.E(err, errorx.CodeInvalid)
+	}
+	if base.Scheme == "" || base.Host == "" {
+		return nil, errorx.E("base url must be absolute", errorx.CodeInvalid, errorx.Fields{"base_url": baseURL})
+	}
+
+	c := &Client{
+		base:       base,
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Client calls the HTTP API of a cronx server.
+type Client struct {
+	base       *url.URL
+	httpClient *http.Client
+	username   string
+	password   string
+	token      string
+}
+
+// Jobs returns the current jobs, sorted using the same query as the jobs page, e.g. name:asc,id:desc.
+// Empty sort means sorted by id.
+func (c *Client) Jobs(ctx context.Context, sort string) (cronx.StatusPageData, error) {
+	query := url.Values{}
+	if sort != "" {
+		query.Set(cronx.QueryParamSort, sort)
+	}
+
+	var res cronx.StatusPageData
+	if err := c.do(ctx, http.MethodGet, "/api/jobs", query, &res); err != nil {
+		return cronx.StatusPageData{}, err
+	}
+	return res, nil
+}
+
+// Histories returns a page of the run histories.
+// Nil request means the first page using the default sort and limit.
+func (c *Client) Histories(ctx context.Context, req *cronx.Request) (cronx.HistoryPageData, error) {
+	query := url.Values{}
+	if req != nil {
+		for k, v := range req.QueryParams() {
+			query.Set(k, v)
+		}
+	}
+
+	var res cronx.HistoryPageData
+	if err := c.do(ctx, http.MethodGet, "/api/histories", query, &res); err != nil {
+		return cronx.HistoryPageData{}, err
+	}
+	return res, nil
+}
+
+// AllHistories iterates every run history starting from the page of the request,
+// fetching the next page once the current page has been consumed.
+// The iteration stops on the first error.
+func (c *Client) AllHistories(ctx context.Context, req *cronx.Request) iter.Seq2[storage.History, error] {
+	return func(yield func(storage.History, error) bool) {
+		for {
+			page, err := c.Histories(ctx, req)
+			if err != nil {
+				yield(storage.History{}, err)
+				return
+			}
+
+			for _, v := range page.Data {
+				if !yield(v, nil) {
+					return
+				}
+			}
+
+			if !page.Pagination.HasNextPage() || page.Pagination.NextPageCursor() == nil {
+				return
+			}
+			req = page.Pagination.NextPageRequest()
+		}
+	}
+}
+
+// CancelJob cancels the current run of the job, see cronx.Manager.Cancel.
+// Requires cronx.RoleOperator.
+func (c *Client) CancelJob(ctx context.Context, id cron.EntryID) error {
+	return c.do(ctx, http.MethodPost, "/api/jobs/"+strconv.Itoa(int(id))+"/cancel", nil, nil)
+}
+
+// ResetBreaker closes the circuit breaker of the job, see cronx.Manager.ResetBreaker.
+// Requires cronx.RoleOperator.
+func (c *Client) ResetBreaker(ctx context.Context, id cron.EntryID) error {
+	return c.do(ctx, http.MethodPost, "/api/jobs/"+strconv.Itoa(int(id))+"/breaker/reset", nil, nil)
+}
+
+// Subscribe calls fn with every event of the server until the context is done or fn returns an error.
+// Events are only sent while subscribed, see cronx.Manager.Subscribe.
+func (c *Client) Subscribe(ctx context.Context, fn func(cronx.Event) error) error {
+	resp, err := c.send(ctx, http.MethodGet, "/api/events", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Read the Server-Sent Events, the event type is also part of the data.
+	var data string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && data != "":
+			var event cronx.Event
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				return errorx.E(err, errorx.CodeUnmarshal)
+			}
+			if err := fn(event); err != nil {
+				return err
+			}
+			data = ""
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return errorx.E(err, errorx.CodeGateway)
+	}
+	return nil
+}
+
+// do sends the request, and decodes the JSON response into res if not nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, res any) error {
+	resp, err := c.send(ctx, method, path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if res == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return errorx.E(err, errorx.CodeUnmarshal)
+	}
+	return nil
+}
+
+// send sends the request to the path under the base URL,
+// and returns an error with the code matching the status if the response is not successful.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values) (*http.Response, error) {
+	target := *c.base
+	target.Path += path
+	target.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), nil)
+	if err != nil {
+		return nil, errorx.E(err, errorx.CodeInvalid)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errorx.E(err, errorx.CodeGateway)
+	}
+	if resp.StatusCode < http.StatusBadRequest {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	return nil, newStatusError(method, target.Path, resp)
+}
+
+// newStatusError creates an error from the unsuccessful response.
+func newStatusError(method, path string, resp *http.Response) error {
+	b, _ := io.ReadAll(resp.Body)
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(b, &body) != nil || body.Error == "" {
+		body.Error = strings.TrimSpace(string(b))
+	}
+
+	code := errorx.CodeGateway
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		code = errorx.CodeInvalid
+	case http.StatusUnauthorized, http.StatusForbidden:
+		code = errorx.CodePermission
+	case http.StatusNotFound:
+		code = errorx.CodeNotFound
+	case http.StatusConflict:
+		code = errorx.CodeConflict
+	}
+
+	return errorx.E(
+		body.Error,
+		code,
+		errorx.Fields{
+			"method": method,
+			"path":   path,
+			"status": resp.StatusCode,
+		},
+	)
+}
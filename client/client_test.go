@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/cronx/internal/cronxtest"
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newClient creates a client of a server with a job that has run the number of times.
+func newClient(t *testing.T, runs int, opts ...Option) (*Client, *cronx.Manager) {
+	t.Helper()
+
+	server, manager := cronxtest.NewServer(t, runs, map[string]cronx.Role{
+		"ops-token":  cronx.RoleOperator,
+		"view-token": cronx.RoleViewer,
+	})
+	c, err := New(server.URL+"/admin/cron/", opts...)
+	require.NoError(t, err)
+	return c, manager
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	_, err := New("localhost:9001")
+	assert.True(t, errorx.Is(err, errorx.CodeInvalid))
+
+	_, err = New("http://localhost:9001/admin/cron", WithToken("secret"))
+	assert.NoError(t, err)
+}
+
+func TestClient_Jobs(t *testing.T) {
+	c, _ := newClient(t, 1, WithToken("view-token"))
+
+	got, err := c.Jobs(context.Background(), "name:desc")
+	require.NoError(t, err)
+	require.Len(t, got.Data, 2)
+	assert.Equal(t, "sendInvoice", got.Data[0].Job.Name)
+	assert.Equal(t, "payBill", got.Data[1].Job.Name)
+	assert.Equal(t, cronx.StatusCodeSuccess, got.Data[1].Job.Status)
+
+	// The token is required.
+	c.token = ""
+	_, err = c.Jobs(context.Background(), "")
+	assert.True(t, errorx.Is(err, errorx.CodePermission))
+}
+
+func TestClient_Histories(t *testing.T) {
+	c, _ := newClient(t, 5, WithToken("view-token"))
+
+	page, err := c.Histories(context.Background(), &cronx.Request{Sort: "id:desc", Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Data, 2)
+	assert.Equal(t, int64(5), page.Data[0].ID)
+	assert.True(t, page.Pagination.HasNextPage())
+
+	var ids []int64
+	for v, err := range c.AllHistories(context.Background(), &cronx.Request{Sort: "id:desc", Limit: 2}) {
+		require.NoError(t, err)
+		ids = append(ids, v.ID)
+	}
+	assert.Equal(t, []int64{5, 4, 3, 2, 1}, ids)
+
+	// Stop iterating early.
+	ids = nil
+	for v := range c.AllHistories(context.Background(), nil) {
+		ids = append(ids, v.ID)
+		break
+	}
+	assert.Equal(t, []int64{5}, ids)
+}
+
+func TestClient_Control(t *testing.T) {
+	c, manager := newClient(t, 0, WithToken("ops-token"))
+	id := manager.GetEntries()[0].ID
+
+	require.NoError(t, c.ResetBreaker(context.Background(), id))
+
+	err := c.CancelJob(context.Background(), id)
+	assert.True(t, errorx.Is(err, errorx.CodeConflict))
+	assert.Equal(t, "job is not running", err.Error())
+
+	err = c.CancelJob(context.Background(), 100)
+	assert.True(t, errorx.Is(err, errorx.CodeNotFound))
+
+	// Controlling the jobs requires an operator.
+	c.token = "view-token"
+	err = c.ResetBreaker(context.Background(), id)
+	assert.True(t, errorx.Is(err, errorx.CodePermission))
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	c, manager := newClient(t, 0, WithToken("view-token"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Keep running the job until the subscription receives the event.
+	job := manager.GetEntries()[0].Job
+	go func() {
+		for ctx.Err() == nil {
+			job.Run()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}()
+
+	stop := errors.New("stop")
+	var got cronx.Event
+	err := c.Subscribe(ctx, func(e cronx.Event) error {
+		got = e
+		return stop
+	})
+	require.ErrorIs(t, err, stop)
+	assert.Equal(t, "payBill", got.Name)
+	assert.Contains(t, []cronx.EventType{cronx.EventRunStarted, cronx.EventRunFinished, cronx.EventStatusChanged}, got.Type)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/cronx/storage"
+)
+
+// history executes the history commands.
+func (c *command) history(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "tail" {
+		return c.historyTail(ctx, args[1:])
+	}
+	return c.historyList(ctx, args)
+}
+
+// historyList prints the run histories, following the next page if requested.
+func (c *command) historyList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.SetOutput(c.w)
+	sort := fs.String("sort", "", "sort the histories, e.g. id:desc")
+	limit := fs.Int("limit", 0, "number of histories per page, 0 means the server default")
+	all := fs.Bool("all", false, "follow the next page until the last page")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	req := &cronx.Request{Sort: *sort, Limit: *limit}
+
+	var histories []storage.History
+	if *all {
+		for v, err := range c.api.AllHistories(ctx, req) {
+			if err != nil {
+				return err
+			}
+			histories = append(histories, v)
+		}
+	} else {
+		data, err := c.api.Histories(ctx, req)
+		if err != nil {
+			return err
+		}
+		histories = data.Data
+	}
+
+	if c.output == outputJSON {
+		return c.printJSON(histories)
+	}
+	return printHistories(c.w, histories)
+}
+
+// historyTail prints the latest run histories, then the finished runs as they happen if requested.
+func (c *command) historyTail(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("history tail", flag.ContinueOnError)
+	fs.SetOutput(c.w)
+	n := fs.Int("n", 10, "number of the latest histories to print")
+	follow := fs.Bool("f", false, "keep printing the finished runs until interrupted")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var histories []storage.History
+	if *n > 0 {
+		data, err := c.api.Histories(ctx, &cronx.Request{Sort: "id:desc", Limit: *n})
+		if err != nil {
+			return err
+		}
+		histories = data.Data
+		slices.Reverse(histories)
+	}
+
+	enc := json.NewEncoder(c.w)
+	if c.output == outputJSON {
+		for _, v := range histories {
+			if err := enc.Encode(v); err != nil {
+				return err
+			}
+		}
+	} else if err := printHistories(c.w, histories); err != nil {
+		return err
+	}
+	if !*follow {
+		return nil
+	}
+
+	return c.api.Subscribe(ctx, func(e cronx.Event) error {
+		if e.Type != cronx.EventRunFinished {
+			return nil
+		}
+		if c.output == outputJSON {
+			return enc.Encode(e)
+		}
+
+		tw := tabwriter.NewWriter(c.w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.HistoryID, e.Key, e.Status, formatTime(e.StartedAt), formatTime(e.Time), e.Latency, e.Error)
+		return tw.Flush()
+	})
+}
+
+// printHistories prints the run histories as a table.
+func printHistories(w io.Writer, histories []storage.History) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tSTARTED AT\tFINISHED AT\tLATENCY\tERROR")
+	for _, v := range histories {
+		name := v.Name
+		if v.Metadata.TotalWave > 1 {
+			name += "#" + strconv.FormatInt(v.Metadata.Wave, 10)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			v.ID, name, v.Status, formatTime(v.StartedAt), formatTime(v.FinishedAt), v.LatencyText, v.Error.Err)
+	}
+	return tw.Flush()
+}
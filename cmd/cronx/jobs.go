@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// jobs executes the jobs commands.
+func (c *command) jobs(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing jobs command, one of list, cancel, reset")
+	}
+
+	switch args[0] {
+	case "list":
+		return c.jobsList(ctx, args[1:])
+	case "cancel":
+		return c.jobsControl(ctx, args[1:], c.api.CancelJob, "cancellation requested")
+	case "reset":
+		return c.jobsControl(ctx, args[1:], c.api.ResetBreaker, "circuit breaker closed")
+	default:
+		return fmt.Errorf("unknown jobs command %q", args[0])
+	}
+}
+
+// jobsList prints the current jobs.
+func (c *command) jobsList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("jobs list", flag.ContinueOnError)
+	fs.SetOutput(c.w)
+	sort := fs.String("sort", "", "sort the jobs, e.g. name:asc,id:desc")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	data, err := c.api.Jobs(ctx, *sort)
+	if err != nil {
+		return err
+	}
+	if c.output == outputJSON {
+		return c.printJSON(data.Data)
+	}
+
+	tw := tabwriter.NewWriter(c.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tPREV RUN\tNEXT RUN\tLATENCY\tERROR")
+	for _, v := range data.Data {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			v.ID, v.Job.Key(), v.Job.Status, formatTime(v.Prev), formatTime(v.Next), v.Job.Latency, v.Job.Error)
+	}
+	return tw.Flush()
+}
+
+// jobsControl calls a control endpoint of a job, e.g. cancel, then prints the message.
+func (c *command) jobsControl(
+	ctx context.Context,
+	args []string,
+	action func(ctx context.Context, id cron.EntryID) error,
+	message string,
+) error {
+	if len(args) != 1 {
+		return errors.New("expected the id of the job, see jobs list")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid id %q: %w", args[0], err)
+	}
+
+	if err := action(ctx, cron.EntryID(id)); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.w, "job %d: %s\n", id, message)
+	return nil
+}
+
+// printJSON prints v as indented JSON.
+func (c *command) printJSON(v any) error {
+	enc := json.NewEncoder(c.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// formatTime formats the time in the local timezone, a dash if zero.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
@@ -0,0 +1,109 @@
+// Command cronx talks to the HTTP API of a running cronx server.
+//
+// Usage:
+//
+//	cronx [flags] jobs list [-sort name:asc]
+//	cronx [flags] jobs cancel <id>
+//	cronx [flags] jobs reset <id>
+//	cronx [flags] history [-sort id:desc] [-limit 100] [-all]
+//	cronx [flags] history tail [-n 10] [-f]
+//
+// The flags default to the CRONX_URL, CRONX_USERNAME, CRONX_PASSWORD, and CRONX_TOKEN environment variables.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rizalgowandy/cronx/client"
+)
+
+// List of output formats.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "cronx:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command of the arguments, writing the output to w.
+func run(ctx context.Context, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("cronx", flag.ContinueOnError)
+	fs.SetOutput(w)
+	baseURL := fs.String("url", env("CRONX_URL", "http://localhost:9001"), "base URL of the cronx server, including the prefix")
+	username := fs.String("username", env("CRONX_USERNAME", ""), "username of basic auth")
+	password := fs.String("password", env("CRONX_PASSWORD", ""), "password of basic auth")
+	token := fs.String("token", env("CRONX_TOKEN", ""), "bearer token")
+	output := fs.String("output", outputTable, "output format, table or json")
+	fs.Usage = func() {
+		fmt.Fprintln(w, "Usage: cronx [flags] <jobs|history> <command> [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *output != outputTable && *output != outputJSON {
+		return fmt.Errorf("unknown output %q", *output)
+	}
+
+	var opts []client.Option
+	if *username != "" {
+		opts = append(opts, client.WithBasicAuth(*username, *password))
+	}
+	if *token != "" {
+		opts = append(opts, client.WithToken(*token))
+	}
+	api, err := client.New(*baseURL, opts...)
+	if err != nil {
+		return err
+	}
+
+	c := &command{
+		api:    api,
+		output: *output,
+		w:      w,
+	}
+
+	args = fs.Args()
+	if len(args) == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	switch args[0] {
+	case "jobs":
+		return c.jobs(ctx, args[1:])
+	case "history":
+		return c.history(ctx, args[1:])
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// command executes the commands against the API.
+type command struct {
+	api    *client.Client
+	output string
+	w      io.Writer
+}
+
+// env returns the environment variable, or the fallback if empty.
+func env(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/cronx/internal/cronxtest"
+	"github.com/rizalgowandy/cronx/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newServer creates a server with a job that has run the number of times.
+func newServer(t *testing.T, runs int) (*httptest.Server, *cronx.Manager) {
+	t.Helper()
+
+	return cronxtest.NewServer(t, runs, map[string]cronx.Role{"secret": cronx.RoleOperator})
+}
+
+func TestRun_JobsList(t *testing.T) {
+	server, _ := newServer(t, 1)
+	flags := []string{"-url", server.URL + "/admin/cron", "-token", "secret"}
+
+	var out bytes.Buffer
+	require.NoError(t, run(context.Background(), append(flags, "jobs", "list", "-sort", "name:desc"), &out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "STATUS")
+	assert.Contains(t, lines[1], "sendInvoice")
+	assert.Contains(t, lines[2], "payBill")
+	assert.Contains(t, lines[2], "SUCCESS")
+
+	out.Reset()
+	require.NoError(t, run(context.Background(), append([]string{"-output", "json"}, append(flags, "jobs", "list")...), &out))
+	var jobs []cronx.StatusData
+	require.NoError(t, json.Unmarshal(out.Bytes(), &jobs))
+	assert.Len(t, jobs, 2)
+
+	// The token is required.
+	err := run(context.Background(), []string{"-url", server.URL + "/admin/cron", "jobs", "list"}, &out)
+	assert.ErrorContains(t, err, "unauthenticated")
+}
+
+func TestRun_JobsControl(t *testing.T) {
+	server, manager := newServer(t, 0)
+	flags := []string{"-url", server.URL + "/admin/cron", "-token", "secret"}
+	id := strconv.Itoa(int(manager.GetEntries()[0].ID))
+
+	var out bytes.Buffer
+	require.NoError(t, run(context.Background(), append(flags, "jobs", "reset", id), &out))
+	assert.Contains(t, out.String(), "circuit breaker closed")
+
+	err := run(context.Background(), append(flags, "jobs", "cancel", id), &out)
+	assert.ErrorContains(t, err, "job is not running")
+}
+
+func TestRun_History(t *testing.T) {
+	server, _ := newServer(t, 5)
+	flags := []string{"-url", server.URL + "/admin/cron", "-token", "secret", "-output", "json"}
+
+	var out bytes.Buffer
+	require.NoError(t, run(context.Background(), append(flags, "history", "-limit", "2"), &out))
+	var histories []storage.History
+	require.NoError(t, json.Unmarshal(out.Bytes(), &histories))
+	assert.Len(t, histories, 2)
+
+	// Follow the next page until the last page.
+	out.Reset()
+	require.NoError(t, run(context.Background(), append(flags, "history", "-limit", "2", "-all"), &out))
+	require.NoError(t, json.Unmarshal(out.Bytes(), &histories))
+	require.Len(t, histories, 5)
+	assert.Equal(t, int64(5), histories[0].ID)
+	assert.Equal(t, int64(1), histories[4].ID)
+}
+
+func TestRun_HistoryTail(t *testing.T) {
+	server, manager := newServer(t, 3)
+	flags := []string{"-url", server.URL + "/admin/cron", "-token", "secret"}
+
+	var out bytes.Buffer
+	require.NoError(t, run(context.Background(), append(flags, "history", "tail", "-n", "2"), &out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[1], "2 "))
+	assert.True(t, strings.HasPrefix(lines[2], "3 "))
+
+	// Follow the finished runs until the context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w := &lineWriter{lines: make(chan string, 10)}
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, append(flags, "history", "tail", "-n", "0", "-f"), w)
+	}()
+
+	// Wait for the header, then run the job until the run is printed.
+	assert.Contains(t, <-w.lines, "STATUS")
+	job := manager.GetEntries()[0].Job
+	for {
+		job.Run()
+		select {
+		case line := <-w.lines:
+			assert.Contains(t, line, "payBill")
+			assert.Contains(t, line, "SUCCESS")
+			cancel()
+			require.NoError(t, <-done)
+			return
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("finished run is not printed")
+		}
+	}
+}
+
+// lineWriter sends every written line to the channel.
+type lineWriter struct {
+	buf   bytes.Buffer
+	lines chan string
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.buf.Write(p)
+	for {
+		line, err := l.buf.ReadString('\n')
+		if err != nil {
+			// Keep the incomplete line for the next write.
+			l.buf.WriteString(line)
+			return len(p), nil
+		}
+		l.lines <- line
+	}
+}
@@ -8,6 +8,8 @@ type contextKey string
 const (
 	// CtxKeyJobMetadata is context for cron job metadata.
 	CtxKeyJobMetadata = contextKey("cron-job-metadata")
+	// CtxKeyRunLogger is context for the log sink of the current run.
+	CtxKeyRunLogger = contextKey("cron-run-logger")
 )
 
 // GetJobMetadata returns job metadata from current context, and status if it exists or not.
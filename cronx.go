@@ -2,13 +2,19 @@ package cronx
 
 import (
 	"context"
+	"io"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rizalgowandy/cronx/page"
 	"github.com/rizalgowandy/cronx/storage"
 	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/rizalgowandy/gdk/pkg/logx"
+	"github.com/rizalgowandy/gdk/pkg/netx"
 	"github.com/rizalgowandy/gdk/pkg/pagination"
 	"github.com/rizalgowandy/gdk/pkg/sortx"
 	"github.com/robfig/cron/v3"
@@ -24,6 +30,18 @@ var (
 	DefaultLocation     = time.Local
 	DefaultStorage      = storage.NewNoopClient()
 	DefaultAlerter      = NewAlerter()
+	// DefaultAbandonThreshold defines how old a running history must be to be marked as abandoned on startup.
+	DefaultAbandonThreshold = time.Hour
+	// DefaultLogLimit defines the maximum size in bytes of the logs captured per run.
+	DefaultLogLimit = 64 * 1024
+	// DefaultAlertCooldown defines how long the same alert of a job is suppressed after being sent.
+	DefaultAlertCooldown = 30 * time.Minute
+	// DefaultAlertFailureThreshold defines the number of failed runs in a row that sends a consecutive failure alert.
+	DefaultAlertFailureThreshold int64 = 3
+	// DefaultBreakerCooldown defines how long the runs are skipped after the circuit breaker is opened.
+	DefaultBreakerCooldown = 5 * time.Minute
+	// DefaultWatchdogInterval defines how often the jobs with a success window are checked.
+	DefaultWatchdogInterval = time.Minute
 )
 
 // NewManager create a command controller with a specific config.
@@ -39,19 +57,43 @@ func NewManager(opts ...Option) *Manager {
 		highPriorityDownJobs: true,
 		storage:              DefaultStorage,
 		alerter:              DefaultAlerter,
+		abandonThreshold:     DefaultAbandonThreshold,
+		logLimit:             DefaultLogLimit,
+		metrics:              NewMetrics(),
+		alertCooldown:        DefaultAlertCooldown,
+		alertThreshold:       DefaultAlertFailureThreshold,
+		alertGate:            nil,
+		successWindows:       map[string]time.Duration{},
+		watchdogInterval:     DefaultWatchdogInterval,
+		watchdogMu:           sync.Mutex{},
+		watched:              map[string]*Job{},
+		watchdogStop:         nil,
+		breakerThreshold:     0,
+		breakerCooldown:      DefaultBreakerCooldown,
+		queueWaitThreshold:   0,
+		splays:               map[string]time.Duration{},
+		jitters:              map[string]time.Duration{},
+		events:               newEventBus(),
+		started:              atomic.Bool{},
+		stopped:              atomic.Bool{},
+		healthRules:          DefaultHealthRules,
 	}
 	for _, opt := range opts {
 		opt(manager)
 	}
+	manager.alertGate = NewAlertGate(manager.alertCooldown)
+
+	// Runs that were interrupted by the previous process will never be finished.
+	manager.abandonHistories()
 
 	commander := cron.New(
 		cron.WithParser(manager.parser),
 		cron.WithLocation(manager.location),
 	)
+	manager.commander = commander
 	if manager.autoStart {
-		commander.Start()
+		manager.Start()
 	}
-	manager.commander = commander
 	manager.createdTime = time.Now().In(manager.location)
 	return manager
 }
@@ -82,6 +124,50 @@ type Manager struct {
 	storage storage.Client
 	// alerter sends an alert on certain unwanted event.
 	alerter AlerterItf
+	// abandonThreshold determines how old a running history must be to be marked as abandoned.
+	// Non-positive value disables the marking.
+	abandonThreshold time.Duration
+	// logLimit determines the maximum size in bytes of the logs captured per run.
+	// Non-positive value disables the capture.
+	logLimit int
+	// metrics collects job run metrics.
+	metrics *Metrics
+	// alertCooldown determines how long the same alert of a job is suppressed after being sent.
+	alertCooldown time.Duration
+	// alertThreshold determines the number of failed runs in a row that sends a consecutive failure alert.
+	alertThreshold int64
+	// alertGate deduplicates alerts per job.
+	alertGate *AlertGate
+	// successWindows determines how often a job is expected to succeed by the job name.
+	successWindows map[string]time.Duration
+	// watchdogInterval determines how often the jobs with a success window are checked.
+	watchdogInterval time.Duration
+	// watchdogMu guards watchdogStop and watched.
+	watchdogMu sync.Mutex
+	// watchdogStop stops the running watchdog.
+	watchdogStop chan struct{}
+	// watched are the jobs with a success window by the job key, kept after being removed.
+	watched map[string]*Job
+	// breakerThreshold determines the number of failed runs in a row that opens the circuit breaker.
+	// Non-positive value disables the circuit breaker.
+	breakerThreshold int64
+	// breakerCooldown determines how long the runs are skipped after the circuit breaker is opened.
+	breakerCooldown time.Duration
+	// queueWaitThreshold determines how long a run can wait for a worker before an alert is sent.
+	// Non-positive value disables the alert.
+	queueWaitThreshold time.Duration
+	// splays determines the maximum deterministic offset of the fire times by the job name.
+	splays map[string]time.Duration
+	// jitters determines the maximum random offset of the fire times by the job name.
+	jitters map[string]time.Duration
+	// events publishes the changes of the jobs to the subscribers.
+	events *eventBus
+	// started determines if the scheduler is running.
+	started atomic.Bool
+	// stopped determines if the scheduler has been stopped since it was last started.
+	stopped atomic.Bool
+	// healthRules determines what counts as unhealthy.
+	healthRules HealthRules
 }
 
 // Schedule sets a job to run at specific time.
@@ -98,6 +184,27 @@ func (m *Manager) ScheduleFunc(spec, name string, cmd func(ctx context.Context)
 	return m.Schedule(spec, NewFuncJob(name, cmd))
 }
 
+// ScheduleWith sets a job to run on a schedule other than a cron specification.
+// Example:
+//
+//	cronx.NewOnceSchedule(time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local))
+//	cronx.NewFixedDelaySchedule(5 * time.Minute)
+//	cronx.NewLastBusinessDaySchedule(17, 0, cronx.NewCalendar(holidays...))
+func (m *Manager) ScheduleWith(schedule cron.Schedule, job JobItf) error {
+	if schedule == nil {
+		err := errorx.New("invalid schedule")
+		m.down(job, 1, 1, err, "")
+		return err
+	}
+	m.register(schedule, describeSchedule(schedule), job, 1, 1)
+	return nil
+}
+
+// ScheduleWithFunc adds a func to the Cron to be run on the given schedule.
+func (m *Manager) ScheduleWithFunc(schedule cron.Schedule, name string, cmd func(ctx context.Context) error) error {
+	return m.ScheduleWith(schedule, NewFuncJob(name, cmd))
+}
+
 // Schedules sets a job to run multiple times at specific time.
 // Symbol */,-? should never be used as separator character.
 // These symbols are reserved for cron specification.
@@ -135,26 +242,126 @@ func (m *Manager) schedule(spec string, job JobItf, waveNumber, totalWave int64)
 	// Check if spec is correct.
 	schedule, err := m.parser.Parse(spec)
 	if err != nil {
-		downJob := NewJob(m, job, waveNumber, totalWave)
-		downJob.Status = StatusCodeDown
-		downJob.Error = err.Error()
-		m.downJobs = append(m.downJobs, downJob)
+		m.down(job, waveNumber, totalWave, err, spec)
 		return err
 	}
 
+	m.register(schedule, spec, job, waveNumber, totalWave)
+	return nil
+}
+
+// down records a job that has failed to be registered.
+func (m *Manager) down(job JobItf, waveNumber, totalWave int64, err error, spec string) {
+	downJob := NewJob(m, job, waveNumber, totalWave)
+	downJob.Status = StatusCodeDown
+	downJob.Schedule = spec
+	downJob.Error = err.Error()
+	m.downJobs = append(m.downJobs, downJob)
+	m.notify(logx.NewContext(), &Alert{
+		Event:  AlertEventDown,
+		Job:    downJob,
+		Err:    err,
+		Fields: errorx.Fields{"spec": spec},
+	})
+}
+
+// register adds the job to the commander using the schedule.
+func (m *Manager) register(schedule cron.Schedule, description string, job JobItf, waveNumber, totalWave int64) {
 	j := NewJob(m, job, waveNumber, totalWave)
+	j.Schedule = description
+	j.schedule = schedule
+	j.SetSuccessWindow(m.successWindows[j.Name], time.Now())
+	m.watchJob(j)
+
+	// Spread the fire times of the job, if configured.
+	splay, jitter := m.splays[j.Name], m.jitters[j.Name]
+	if splay > 0 || jitter > 0 {
+		j.offsets = NewOffsetSchedule(schedule, j.Key()+"@"+netx.GetIPv4(), splay, jitter)
+		schedule = j.offsets
+	}
+
 	j.EntryID = m.commander.Schedule(schedule, j)
-	return nil
+}
+
+// afterRun updates the schedules that depend on the outcome of the current run.
+func (m *Manager) afterRun(j *Job) {
+	switch s := j.schedule.(type) {
+	case *OnceSchedule:
+		if atomic.CompareAndSwapUint32(&j.status, statusSuccess, statusCompleted) {
+			j.UpdateStatus()
+		}
+	case *FixedDelaySchedule:
+		// The next run is counted from now.
+		s.finish(time.Now())
+	}
+}
+
+// notify sends the alert using the alerter.
+// Repeated alerts of the same job are suppressed until the cooldown has passed.
+func (m *Manager) notify(ctx context.Context, alert *Alert) {
+	if alert.Time.IsZero() {
+		alert.Time = time.Now()
+	}
+
+	key := ""
+	if alert.Job != nil {
+		key = alert.Job.Key()
+	}
+
+	switch alert.Event {
+	case AlertEventRecovery:
+		m.alertGate.Reset(key)
+	case AlertEventDown, AlertEventConsecutiveFailure:
+		// Both events happen only once, no need to be suppressed.
+	default:
+		if !m.alertGate.Allow(key, alert.Event, alert.Time) {
+			return
+		}
+	}
+
+	m.alerter.Notify(ctx, alert)
+}
+
+// abandonHistories marks the running histories from this machine
+// that are older than the threshold as abandoned.
+func (m *Manager) abandonHistories() {
+	if m.abandonThreshold <= 0 {
+		return
+	}
+
+	ctx := logx.NewContext()
+	now := time.Now()
+	total, err := m.storage.AbandonHistories(ctx, &storage.AbandonFilter{
+		MachineID:     netx.GetIPv4(),
+		RunningStatus: StatusCodeRunning.String(),
+		StartedBefore: now.Add(-m.abandonThreshold),
+		Status:        StatusCodeAbandoned.String(),
+		StatusCode:    int64(statusAbandoned),
+		FinishedAt:    now,
+	})
+	if err != nil {
+		logx.ERR(ctx, errorx.E(err), "abandon running histories must success")
+		return
+	}
+	if total > 0 {
+		logx.INF(ctx, logx.KV{"total": total}, "running histories have been marked as abandoned")
+	}
 }
 
 // Start starts jobs from running at the next scheduled time.
 func (m *Manager) Start() {
 	m.commander.Start()
+	m.started.Store(true)
+	m.stopped.Store(false)
+	m.startWatchdog()
 }
 
 // Stop stops active jobs from running at the next scheduled time.
 func (m *Manager) Stop() {
 	m.commander.Stop()
+	m.started.Store(false)
+	m.stopped.Store(true)
+	m.stopWatchdog()
 }
 
 // GetEntries returns all the current registered jobs.
@@ -175,6 +382,46 @@ func (m *Manager) Remove(id cron.EntryID) {
 	m.commander.Remove(id)
 }
 
+// Metrics returns the collector of the job run metrics of this manager.
+func (m *Manager) Metrics() *Metrics {
+	return m.metrics
+}
+
+// WriteMetrics writes the job metrics in the Prometheus text format.
+func (m *Manager) WriteMetrics(w io.Writer) error {
+	if err := m.metrics.Write(w); err != nil {
+		return errorx.E(err)
+	}
+
+	entries := m.commander.Entries()
+	next := make([]metricSample, 0, len(entries))
+	for _, v := range entries {
+		if v.Next.IsZero() {
+			continue
+		}
+		job, ok := v.Job.(*Job)
+		if !ok {
+			continue
+		}
+		next = append(next, metricSample{
+			name:   MetricJobNextRun,
+			labels: [][2]string{{"entry_id", strconv.Itoa(int(v.ID))}, {"name", job.Name}},
+			value:  float64(v.Next.UnixNano()) / 1e9,
+		})
+	}
+
+	enc := newMetricEncoder(w)
+	enc.family(MetricJobNextRun, "Unix timestamp of the next scheduled job run.", "gauge", next)
+	enc.family(MetricJobsDown, "Number of jobs that have failed to be registered.", "gauge", []metricSample{
+		{name: MetricJobsDown, labels: nil, value: float64(len(m.downJobs))},
+	})
+	if err := enc.flush(); err != nil {
+		return errorx.E(err)
+	}
+
+	return nil
+}
+
 // GetInfo returns command controller basic information.
 func (m *Manager) GetInfo() map[string]interface{} {
 	currentTime := time.Now().In(m.location)
@@ -207,6 +454,7 @@ func (m *Manager) GetStatusData(sortQuery string) StatusPageData {
 		data[k].Job = v.Job.(*Job)
 		data[k].Next = v.Next
 		data[k].Prev = v.Prev
+		data[k].NextOffset = data[k].Job.offsetText(v.Next)
 	}
 
 	// Sort data.
@@ -235,6 +483,7 @@ func (m *Manager) GetStatusData(sortQuery string) StatusPageData {
 			listStatus[idx].Job = v.Job
 			listStatus[idx].Next = v.Next
 			listStatus[idx].Prev = v.Prev
+			listStatus[idx].NextOffset = v.NextOffset
 		}
 	} else {
 		// Register other jobs.
@@ -243,6 +492,7 @@ func (m *Manager) GetStatusData(sortQuery string) StatusPageData {
 			listStatus[k].Job = v.Job
 			listStatus[k].Next = v.Next
 			listStatus[k].Prev = v.Prev
+			listStatus[k].NextOffset = v.NextOffset
 		}
 
 		// Register down jobs.
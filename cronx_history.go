@@ -8,8 +8,16 @@ import (
 //go:generate gomodifytags -all --quiet -w -file cronx_history.go -clear-tags
 //go:generate gomodifytags -all --quiet --skip-unexported -w -file cronx_history.go -add-tags json
 
+// HistoryPageData is the data of the histories page, the dot of the histories.html template.
+// The fields are a stable contract for the overridden templates,
+// fields are only added, never renamed or removed within a major version.
 type HistoryPageData struct {
-	Data       []storage.History `json:"data"`
-	Pagination Response          `json:"pagination"`
-	Sort       pagination.Sort   `json:"sort"`
+	// Data are the run histories, each is the dot of the history_cells template.
+	Data []storage.History `json:"data"`
+	// Pagination contains the URI of the previous and next page, nil if none.
+	Pagination Response `json:"pagination"`
+	// Sort describes the sorted columns of the table.
+	Sort pagination.Sort `json:"sort"`
+	// BasePath is the path prefix of the links on the page.
+	BasePath string `json:"base_path"`
 }
@@ -20,9 +20,18 @@ type StatusData struct {
 	Next time.Time `json:"next"`
 	// Prev defines the last run of the current job.
 	Prev time.Time `json:"prev"`
+	// NextOffset defines the splay and jitter included in the next schedule, empty if none.
+	NextOffset string `json:"next_offset"`
 }
 
+// StatusPageData is the data of the jobs page, the dot of the jobs.html template.
+// The fields are a stable contract for the overridden templates,
+// fields are only added, never renamed or removed within a major version.
 type StatusPageData struct {
-	Data []StatusData    `json:"data"`
+	// Data are the jobs, each is the dot of the job_cells template.
+	Data []StatusData `json:"data"`
+	// Sort describes the sorted columns of the table.
 	Sort pagination.Sort `json:"sort"`
+	// BasePath is the path prefix of the links on the page.
+	BasePath string `json:"base_path"`
 }
@@ -0,0 +1,131 @@
+package cronx
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rizalgowandy/cronx/storage"
+	"github.com/robfig/cron/v3"
+)
+
+// DefaultEventBuffer defines how many events are kept for a slow subscriber before the next events are dropped.
+var DefaultEventBuffer = 64
+
+// EventType describes what happens to a job.
+type EventType string
+
+func (e EventType) String() string {
+	return string(e)
+}
+
+const (
+	// EventRunStarted describes that a run has started.
+	EventRunStarted EventType = "RUN_STARTED"
+	// EventRunFinished describes that a run has finished.
+	EventRunFinished EventType = "RUN_FINISHED"
+	// EventStatusChanged describes that the status of the job has changed.
+	EventStatusChanged EventType = "STATUS_CHANGED"
+)
+
+// Event describes a change of a job published by the manager.
+type Event struct {
+	Type      EventType    `json:"type"`
+	Time      time.Time    `json:"time"`
+	EntryID   cron.EntryID `json:"entry_id"`
+	Name      string       `json:"name"`
+	Key       string       `json:"key"`
+	Status    StatusCode   `json:"status"`
+	StartedAt time.Time    `json:"started_at"`
+	Latency   string       `json:"latency"`
+	Error     string       `json:"error"`
+	// HistoryID is the id of the run history, zero if the event is not about a run.
+	HistoryID int64 `json:"history_id"`
+	// PrevRun is the time the job has been scheduled to run last.
+	PrevRun time.Time `json:"prev_run"`
+	// NextRun is the time the job is scheduled to run next.
+	NextRun time.Time `json:"next_run"`
+	// NextOffset is the splay and jitter included in the next run, empty if none.
+	NextOffset string `json:"next_offset"`
+}
+
+// newEventBus creates a bus that publishes events to every subscriber.
+func newEventBus() *eventBus {
+	return &eventBus{
+		mu:          sync.Mutex{},
+		subscribers: map[chan Event]struct{}{},
+	}
+}
+
+type eventBus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// subscribe returns a channel receiving the published events, and a func to stop receiving them.
+func (b *eventBus) subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+		})
+	}
+}
+
+// publish sends the event to every subscriber without waiting,
+// so a slow subscriber never blocks the job.
+func (b *eventBus) publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the events of every job, and a func to stop receiving them.
+// Events are dropped if the channel is full, see DefaultEventBuffer.
+func (m *Manager) Subscribe() (<-chan Event, func()) {
+	return m.events.subscribe(DefaultEventBuffer)
+}
+
+// publish sends the event about the job to the subscribers.
+// The history is the run the event is about, nil if none.
+func (j *Job) publish(eventType EventType, history *storage.History) {
+	if j.manager == nil || j.manager.events == nil {
+		return
+	}
+
+	event := Event{
+		Type:       eventType,
+		Time:       time.Now(),
+		EntryID:    j.EntryID,
+		Name:       j.Name,
+		Key:        j.Key(),
+		Status:     j.Status,
+		StartedAt:  j.StartedAt,
+		Latency:    j.Latency,
+		Error:      j.Error,
+		HistoryID:  0,
+		PrevRun:    j.PrevRun,
+		NextRun:    j.NextRun,
+		NextOffset: j.offsetText(j.NextRun),
+	}
+	// A run skipped by the circuit breaker has not started the job, so the run is described by its history.
+	if history != nil {
+		event.HistoryID = history.ID
+		event.StartedAt = history.StartedAt
+		event.Latency = history.LatencyText
+	}
+	j.manager.events.publish(event)
+}
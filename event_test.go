@@ -0,0 +1,60 @@
+package cronx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(WithAutoStartDisabled(), WithStorage(&historyRecorder{}))
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", func(context.Context) error { return nil }))
+	j := manager.GetEntries()[0].Job.(*Job)
+
+	events, unsubscribe := manager.Subscribe()
+	j.Run()
+	unsubscribe()
+	unsubscribe()
+
+	var got []Event
+	for len(events) > 0 {
+		got = append(got, <-events)
+	}
+	require.Len(t, got, 4)
+
+	assert.Equal(t, EventStatusChanged, got[0].Type)
+	assert.Equal(t, StatusCodeRunning, got[0].Status)
+	assert.Equal(t, EventRunStarted, got[1].Type)
+	assert.Equal(t, int64(1), got[1].HistoryID)
+	assert.Equal(t, EventStatusChanged, got[2].Type)
+	assert.Equal(t, StatusCodeSuccess, got[2].Status)
+	assert.Equal(t, EventRunFinished, got[3].Type)
+	assert.Equal(t, "payBill", got[3].Name)
+	assert.Equal(t, j.EntryID, got[3].EntryID)
+	assert.NotEmpty(t, got[3].Latency)
+	assert.False(t, got[3].StartedAt.IsZero())
+	assert.Equal(t, j.PrevRun, got[3].PrevRun)
+	assert.Equal(t, j.NextRun, got[3].NextRun)
+
+	// No more events after unsubscribing.
+	j.Run()
+	assert.Empty(t, events)
+}
+
+func TestEventBus_publishDropsWhenFull(t *testing.T) {
+	t.Parallel()
+
+	bus := newEventBus()
+	events, unsubscribe := bus.subscribe(1)
+	defer unsubscribe()
+
+	bus.publish(Event{Type: EventRunStarted})
+	bus.publish(Event{Type: EventRunFinished})
+
+	require.Len(t, events, 1)
+	assert.Equal(t, EventRunStarted, (<-events).Type)
+}
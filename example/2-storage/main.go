@@ -32,6 +32,8 @@ type payBill struct{}
 
 func (p payBill) Run(ctx context.Context) error {
 	logx.INF(ctx, logx.KV{"job": fn.Name()}, "every 1 min pay bill")
+	// Lines written to the run logger are stored with the history.
+	cronx.Logger(ctx).Info("bills paid", logx.KV{"total": 3})
 	return nil
 }
 
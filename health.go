@@ -0,0 +1,197 @@
+package cronx
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/rizalgowandy/cronx/storage"
+)
+
+// HealthCheck is a check of the health of the manager.
+type HealthCheck string
+
+// List of health checks.
+const (
+	// HealthCheckScheduler fails when the scheduler is not running, e.g. stopped.
+	// A manager created by WithAutoStartDisabled passes the check until it is started and stopped.
+	HealthCheckScheduler HealthCheck = "scheduler"
+	// HealthCheckDownJobs fails when more jobs than HealthRules.MaxDownJobs have failed to be registered.
+	HealthCheckDownJobs HealthCheck = "down_jobs"
+	// HealthCheckStorage fails when the storage cannot be reached, see storage.PingerItf.
+	HealthCheckStorage HealthCheck = "storage"
+	// HealthCheckStuckJobs fails when a run has been running longer than HealthRules.StuckThreshold.
+	HealthCheckStuckJobs HealthCheck = "stuck_jobs"
+)
+
+// DefaultHealthRules only restarts the manager when the scheduler is not running,
+// and stops sending traffic to it when the storage is unreachable as well.
+// The down jobs and stuck jobs checks are opt-in, since a single broken spec or a single long run
+// would otherwise take the manager out of service.
+var DefaultHealthRules = HealthRules{
+	Liveness:       []HealthCheck{HealthCheckScheduler},
+	Readiness:      []HealthCheck{HealthCheckScheduler, HealthCheckStorage},
+	MaxDownJobs:    0,
+	StuckThreshold: time.Hour,
+	StorageTimeout: 5 * time.Second,
+}
+
+// HealthRules determines what counts as unhealthy.
+type HealthRules struct {
+	// Liveness are the checks that fail the liveness check.
+	Liveness []HealthCheck
+	// Readiness are the checks that fail the readiness check.
+	Readiness []HealthCheck
+	// MaxDownJobs is the number of down jobs tolerated.
+	MaxDownJobs int
+	// StuckThreshold is how long a run can be running before the job is stuck.
+	// Non-positive value means a job is never stuck.
+	StuckThreshold time.Duration
+	// StorageTimeout is how long the storage can take to answer a ping.
+	// Non-positive value means no timeout other than the one of the context.
+	StorageTimeout time.Duration
+}
+
+// HealthReport describes the result of the liveness or readiness check.
+type HealthReport struct {
+	// Status is UP if every check has passed, otherwise DOWN.
+	Status StatusCode `json:"status"`
+	// Checks are the results of the configured checks.
+	Checks []HealthCheckResult `json:"checks"`
+}
+
+// Healthy determines if every check has passed.
+func (r HealthReport) Healthy() bool {
+	return r.Status == StatusCodeUp
+}
+
+// HealthCheckResult describes the result of a single check.
+type HealthCheckResult struct {
+	Name HealthCheck `json:"name"`
+	// Status is UP if the check has passed, otherwise DOWN.
+	Status StatusCode `json:"status"`
+	// Message explains the result of the check.
+	Message string `json:"message"`
+	// Jobs are the keys of the jobs failing the check, see Job.Key.
+	Jobs []string `json:"jobs,omitempty"`
+}
+
+// Liveness returns whether the manager is alive, using the liveness checks of the health rules.
+// A failed liveness check means the process should be restarted.
+func (m *Manager) Liveness(ctx context.Context) HealthReport {
+	return m.checkHealth(ctx, m.healthRules.Liveness)
+}
+
+// Readiness returns whether the manager is ready, using the readiness checks of the health rules.
+// A failed readiness check means no traffic should be sent to the manager until it passes again,
+// restarting the process may not help.
+func (m *Manager) Readiness(ctx context.Context) HealthReport {
+	return m.checkHealth(ctx, m.healthRules.Readiness)
+}
+
+// checkHealth runs the checks in order, the report is DOWN if any check fails.
+func (m *Manager) checkHealth(ctx context.Context, checks []HealthCheck) HealthReport {
+	report := HealthReport{
+		Status: StatusCodeUp,
+		Checks: make([]HealthCheckResult, 0, len(checks)),
+	}
+	for _, v := range checks {
+		var res HealthCheckResult
+		switch v {
+		case HealthCheckScheduler:
+			res = m.checkScheduler()
+		case HealthCheckDownJobs:
+			res = m.checkDownJobs()
+		case HealthCheckStorage:
+			res = m.checkStorage(ctx)
+		case HealthCheckStuckJobs:
+			res = m.checkStuckJobs(time.Now())
+		default:
+			res = HealthCheckResult{Status: StatusCodeDown, Message: "unknown check"}
+		}
+
+		res.Name = v
+		if res.Status == "" {
+			res.Status = StatusCodeUp
+		}
+		if res.Status != StatusCodeUp {
+			report.Status = StatusCodeDown
+		}
+		report.Checks = append(report.Checks, res)
+	}
+	return report
+}
+
+func (m *Manager) checkScheduler() HealthCheckResult {
+	if m.started.Load() {
+		return HealthCheckResult{Message: "scheduler is running"}
+	}
+	if !m.autoStart && !m.stopped.Load() {
+		return HealthCheckResult{Message: "scheduler has not been started, auto start is disabled"}
+	}
+	return HealthCheckResult{Status: StatusCodeDown, Message: "scheduler is not running"}
+}
+
+func (m *Manager) checkDownJobs() HealthCheckResult {
+	var keys []string
+	for _, v := range m.downJobs {
+		keys = append(keys, v.Key())
+	}
+
+	res := HealthCheckResult{
+		Message: strconv.Itoa(len(keys)) + " jobs are down, " + strconv.Itoa(m.healthRules.MaxDownJobs) + " tolerated",
+		Jobs:    keys,
+	}
+	if len(keys) > m.healthRules.MaxDownJobs {
+		res.Status = StatusCodeDown
+	}
+	return res
+}
+
+func (m *Manager) checkStorage(ctx context.Context) HealthCheckResult {
+	pinger, ok := m.storage.(storage.PingerItf)
+	if !ok {
+		return HealthCheckResult{Message: "storage does not support ping"}
+	}
+
+	if m.healthRules.StorageTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.healthRules.StorageTimeout)
+		defer cancel()
+	}
+	if err := pinger.Ping(ctx); err != nil {
+		return HealthCheckResult{Status: StatusCodeDown, Message: "storage is unreachable: " + err.Error()}
+	}
+	return HealthCheckResult{Message: "storage is reachable"}
+}
+
+func (m *Manager) checkStuckJobs(now time.Time) HealthCheckResult {
+	threshold := m.healthRules.StuckThreshold
+	if threshold <= 0 {
+		return HealthCheckResult{Message: "stuck threshold is disabled"}
+	}
+
+	var keys []string
+	for _, v := range m.commander.Entries() {
+		job, ok := v.Job.(*Job)
+		if !ok {
+			continue
+		}
+		since := atomic.LoadInt64(&job.runningSince)
+		if since > 0 && now.Sub(time.Unix(0, since)) > threshold {
+			keys = append(keys, job.Key())
+		}
+	}
+	slices.Sort(keys)
+
+	res := HealthCheckResult{
+		Message: strconv.Itoa(len(keys)) + " jobs have been running longer than " + threshold.String(),
+		Jobs:    keys,
+	}
+	if len(keys) > 0 {
+		res.Status = StatusCodeDown
+	}
+	return res
+}
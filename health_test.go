@@ -0,0 +1,221 @@
+package cronx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pingerStub is a storage answering the ping with err.
+type pingerStub struct {
+	storageStub
+	err error
+}
+
+func (p pingerStub) Ping(context.Context) error {
+	return p.err
+}
+
+func TestManager_Liveness(t *testing.T) {
+	t.Parallel()
+
+	// A manager deliberately left unstarted is alive.
+	manager := NewManager(WithAutoStartDisabled())
+	got := manager.Liveness(context.Background())
+	assert.True(t, got.Healthy())
+	assert.Equal(t, []HealthCheckResult{
+		{Name: HealthCheckScheduler, Status: StatusCodeUp, Message: "scheduler has not been started, auto start is disabled"},
+	}, got.Checks)
+
+	manager.Start()
+	assert.True(t, manager.Liveness(context.Background()).Healthy())
+
+	manager.Stop()
+	got = manager.Liveness(context.Background())
+	assert.False(t, got.Healthy())
+	assert.Equal(t, []HealthCheckResult{
+		{Name: HealthCheckScheduler, Status: StatusCodeDown, Message: "scheduler is not running"},
+	}, got.Checks)
+
+	manager.Start()
+	assert.True(t, manager.Liveness(context.Background()).Healthy())
+	manager.Stop()
+
+	// A manager started automatically is dead once stopped.
+	manager = NewManager()
+	assert.True(t, manager.Liveness(context.Background()).Healthy())
+	manager.Stop()
+	assert.False(t, manager.Liveness(context.Background()).Healthy())
+}
+
+func TestManager_Readiness(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		rules   func(*HealthRules)
+		storage error
+		down    bool
+		want    map[HealthCheck]StatusCode
+	}{
+		{
+			name: "Healthy",
+			want: map[HealthCheck]StatusCode{
+				HealthCheckScheduler: StatusCodeUp,
+				HealthCheckStorage:   StatusCodeUp,
+			},
+		},
+		{
+			name: "Down job not checked by default",
+			down: true,
+			want: map[HealthCheck]StatusCode{
+				HealthCheckScheduler: StatusCodeUp,
+				HealthCheckStorage:   StatusCodeUp,
+			},
+		},
+		{
+			name:  "Down job",
+			rules: func(r *HealthRules) { r.Readiness = append(r.Readiness, HealthCheckDownJobs) },
+			down:  true,
+			want: map[HealthCheck]StatusCode{
+				HealthCheckScheduler: StatusCodeUp,
+				HealthCheckStorage:   StatusCodeUp,
+				HealthCheckDownJobs:  StatusCodeDown,
+			},
+		},
+		{
+			name: "Down job tolerated",
+			rules: func(r *HealthRules) {
+				r.Readiness = append(r.Readiness, HealthCheckDownJobs)
+				r.MaxDownJobs = 1
+			},
+			down: true,
+			want: map[HealthCheck]StatusCode{
+				HealthCheckScheduler: StatusCodeUp,
+				HealthCheckStorage:   StatusCodeUp,
+				HealthCheckDownJobs:  StatusCodeUp,
+			},
+		},
+		{
+			name:    "Storage unreachable",
+			storage: errors.New("connection refused"),
+			want: map[HealthCheck]StatusCode{
+				HealthCheckScheduler: StatusCodeUp,
+				HealthCheckStorage:   StatusCodeDown,
+			},
+		},
+		{
+			name:    "Only the configured checks",
+			rules:   func(r *HealthRules) { r.Readiness = []HealthCheck{HealthCheckScheduler} },
+			storage: errors.New("connection refused"),
+			down:    true,
+			want: map[HealthCheck]StatusCode{
+				HealthCheckScheduler: StatusCodeUp,
+			},
+		},
+		{
+			name:  "Unknown check",
+			rules: func(r *HealthRules) { r.Readiness = []HealthCheck{"disk"} },
+			want: map[HealthCheck]StatusCode{
+				"disk": StatusCodeDown,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rules := DefaultHealthRules
+			if tt.rules != nil {
+				tt.rules(&rules)
+			}
+			manager := NewManager(WithStorage(pingerStub{err: tt.storage}), WithHealthRules(rules))
+			defer manager.Stop()
+			require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", func(context.Context) error { return nil }))
+			if tt.down {
+				require.Error(t, manager.ScheduleFunc("invalid", "sendInvoice", func(context.Context) error { return nil }))
+			}
+
+			got := manager.Readiness(context.Background())
+			statuses := map[HealthCheck]StatusCode{}
+			for _, v := range got.Checks {
+				statuses[v.Name] = v.Status
+			}
+			assert.Equal(t, tt.want, statuses)
+
+			healthy := true
+			for _, v := range tt.want {
+				healthy = healthy && v == StatusCodeUp
+			}
+			assert.Equal(t, healthy, got.Healthy())
+		})
+	}
+}
+
+func TestManager_Readiness_StuckJobs(t *testing.T) {
+	t.Parallel()
+
+	rules := DefaultHealthRules
+	rules.Readiness = []HealthCheck{HealthCheckStuckJobs}
+	rules.StuckThreshold = 10 * time.Millisecond
+	manager := NewManager(WithAutoStartDisabled(), WithHealthRules(rules))
+
+	started := make(chan struct{}, 1)
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", blockingJob(started)))
+	assert.True(t, manager.Readiness(context.Background()).Healthy())
+
+	done := make(chan struct{})
+	go func() {
+		manager.GetEntries()[0].Job.Run()
+		close(done)
+	}()
+	<-started
+
+	require.Eventually(t, func() bool {
+		return !manager.Readiness(context.Background()).Healthy()
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"payBill"}, manager.Readiness(context.Background()).Checks[0].Jobs)
+
+	require.NoError(t, manager.Cancel("payBill", "admin"))
+	<-done
+	assert.True(t, manager.Readiness(context.Background()).Healthy())
+}
+
+func TestServerController_Health(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(WithAutoStartDisabled())
+	handler := NewHandler(
+		manager,
+		WithPrefix("/admin/cron"),
+		WithAuth(NewTokenAuth(map[string]Role{"secret": RoleOperator})),
+	)
+
+	// The probes are public.
+	manager.Start()
+	for _, path := range []string{"/admin/cron/healthz", "/admin/cron/readyz"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+	}
+
+	manager.Stop()
+	for _, path := range []string{"/admin/cron/healthz", "/admin/cron/readyz"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code, path)
+
+		var got HealthReport
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Equal(t, StatusCodeDown, got.Status)
+	}
+}
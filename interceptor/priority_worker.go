@@ -0,0 +1,267 @@
+package interceptor
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+)
+
+// List of metric names exposed by the priority worker pool.
+const (
+	MetricWorkerPoolGroupCapacity = "cronx_worker_pool_group_capacity"
+	MetricWorkerPoolGroupInUse    = "cronx_worker_pool_group_in_use"
+	MetricWorkerPoolGroupQueued   = "cronx_worker_pool_group_queued"
+)
+
+// OverflowPoolGroup is the group name of the overflow workers in the metrics.
+const OverflowPoolGroup = "overflow"
+
+// PoolGroup describes a group of jobs that have their own workers.
+type PoolGroup struct {
+	// Name of the group.
+	Name string
+	// Capacity is the number of workers only used by the jobs of the group.
+	Capacity int
+	// Jobs are the job names of the group.
+	Jobs []string
+}
+
+// PriorityWorkerPoolConfig describes the priority worker pool.
+type PriorityWorkerPoolConfig struct {
+	// Groups have their own workers.
+	// Jobs without a group are not limited by the pool.
+	Groups []PoolGroup
+	// Overflow is the number of workers shared by every group once its own workers are in use.
+	Overflow int
+	// Priorities of the jobs by the job name.
+	// A waiting job with higher priority acquires a worker first. Default is 0.
+	Priorities map[string]int
+}
+
+// PriorityWorkerPool is a middleware that limits total cron that can run at a time per group.
+// Unlike WorkerPool, a burst of jobs from one group cannot starve the jobs from other groups,
+// and waiting jobs acquire a worker by priority instead of by arrival.
+// While waiting, the job status is QUEUED.
+// The queue depth is exposed through the manager metrics, or the metrics of WithPoolMetrics,
+// labelled by the name of WithPoolName, and the time spent waiting is exposed through the manager metrics.
+// Use NewPriorityWorkerPool instead to remove the pool from the metrics once it is no longer used.
+func PriorityWorkerPool(cfg PriorityWorkerPoolConfig, opts ...PoolOption) cronx.Interceptor {
+	pool, _ := NewPriorityWorkerPool(cfg, opts...)
+	return pool
+}
+
+// NewPriorityWorkerPool returns a PriorityWorkerPool middleware,
+// and a function that removes the pool from the metrics once the pool is no longer used.
+func NewPriorityWorkerPool(cfg PriorityWorkerPoolConfig, opts ...PoolOption) (cronx.Interceptor, func()) {
+	pool := newPriorityPool(cfg)
+	poolCfg := newPoolConfig(opts)
+	gauges := newPoolGauges(poolCfg, func(metrics *cronx.Metrics) []func() {
+		return pool.registerMetrics(poolCfg.name, metrics)
+	})
+
+	return func(ctx context.Context, job *cronx.Job, handler cronx.Handler) error {
+		gauges.attach(job.Metrics())
+
+		group, ok := pool.jobGroups[job.Name]
+		if !ok {
+			return handler(ctx, job)
+		}
+
+		start := time.Now()
+		w, err := pool.acquire(ctx, group, cfg.Priorities[job.Name], func(queued bool) {
+			job.SetQueued(queued)
+		})
+		job.RecordQueueWait(time.Since(start))
+		if err != nil {
+			return errorx.E(err, errorx.Op(job.Name), errorx.Fields{"pool_group": group.name})
+		}
+
+		// Release the worker.
+		defer pool.release(w)
+
+		return handler(ctx, job)
+	}, gauges.close
+}
+
+func newPriorityPool(cfg PriorityWorkerPoolConfig) *priorityPool {
+	p := &priorityPool{
+		mu:        sync.Mutex{},
+		groups:    make([]*poolGroup, 0, len(cfg.Groups)),
+		jobGroups: map[string]*poolGroup{},
+		overflow:  &poolGroup{name: OverflowPoolGroup, capacity: cfg.Overflow},
+		waiters:   nil,
+		seq:       0,
+	}
+	for _, v := range cfg.Groups {
+		group := &poolGroup{name: v.Name, capacity: v.Capacity}
+		p.groups = append(p.groups, group)
+		for _, name := range v.Jobs {
+			p.jobGroups[name] = group
+		}
+	}
+	return p
+}
+
+// priorityPool hands out the workers of each group to the waiters by priority.
+type priorityPool struct {
+	mu        sync.Mutex
+	groups    []*poolGroup
+	jobGroups map[string]*poolGroup
+	overflow  *poolGroup
+	// waiters is ordered by priority descending, then by arrival.
+	waiters []*poolWaiter
+	seq     uint64
+}
+
+type poolGroup struct {
+	name     string
+	capacity int
+	inUse    int
+	queued   int
+}
+
+type poolWaiter struct {
+	group    *poolGroup
+	priority int
+	seq      uint64
+	ready    chan struct{}
+	granted  bool
+	overflow bool
+}
+
+// acquire waits until a worker of the group or an overflow worker is available.
+// onQueue is called with true if the worker is not immediately available,
+// and with false once the waiting is over.
+func (p *priorityPool) acquire(
+	ctx context.Context,
+	group *poolGroup,
+	priority int,
+	onQueue func(queued bool),
+) (*poolWaiter, error) {
+	p.mu.Lock()
+	p.seq++
+	w := &poolWaiter{
+		group:    group,
+		priority: priority,
+		seq:      p.seq,
+		ready:    make(chan struct{}),
+	}
+	idx := sort.Search(len(p.waiters), func(i int) bool {
+		return p.waiters[i].priority < priority
+	})
+	p.waiters = append(p.waiters, nil)
+	copy(p.waiters[idx+1:], p.waiters[idx:])
+	p.waiters[idx] = w
+	group.queued++
+	p.dispatch()
+	granted := w.granted
+	p.mu.Unlock()
+
+	if granted {
+		return w, nil
+	}
+
+	onQueue(true)
+	defer onQueue(false)
+
+	select {
+	case <-w.ready:
+		return w, nil
+	case <-ctx.Done():
+	}
+
+	p.mu.Lock()
+	if w.granted {
+		p.mu.Unlock()
+		p.release(w)
+		return nil, ctx.Err()
+	}
+	for k, v := range p.waiters {
+		if v == w {
+			p.waiters = append(p.waiters[:k], p.waiters[k+1:]...)
+			break
+		}
+	}
+	group.queued--
+	p.mu.Unlock()
+
+	return nil, ctx.Err()
+}
+
+// release returns the worker, and hands it out to the next waiter.
+func (p *priorityPool) release(w *poolWaiter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if w.overflow {
+		p.overflow.inUse--
+	} else {
+		w.group.inUse--
+	}
+	p.dispatch()
+}
+
+// dispatch grants the available workers to the waiters by order.
+// A waiter whose group has no worker left does not block the waiters of other groups.
+func (p *priorityPool) dispatch() {
+	for i := 0; i < len(p.waiters); {
+		w := p.waiters[i]
+		switch {
+		case w.group.inUse < w.group.capacity:
+			w.group.inUse++
+		case p.overflow.inUse < p.overflow.capacity:
+			p.overflow.inUse++
+			w.overflow = true
+		default:
+			i++
+			continue
+		}
+
+		p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+		w.group.queued--
+		w.granted = true
+		close(w.ready)
+	}
+}
+
+// registerMetrics exposes every group of the pool, and returns the functions that remove them.
+func (p *priorityPool) registerMetrics(name string, metrics *cronx.Metrics) []func() {
+	var unregisters []func()
+	groups := append([]*poolGroup{p.overflow}, p.groups...)
+	for _, v := range groups {
+		group := v
+		labels := map[string]string{"pool": name, "group": group.name}
+		unregisters = append(unregisters,
+			metrics.RegisterGauge(
+				MetricWorkerPoolGroupCapacity,
+				"Number of workers in each group of the priority worker pool.",
+				labels,
+				func() float64 { return float64(group.capacity) },
+			),
+			metrics.RegisterGauge(
+				MetricWorkerPoolGroupInUse,
+				"Number of workers in each group of the priority worker pool currently running a job.",
+				labels,
+				func() float64 { return p.read(&group.inUse) },
+			),
+			metrics.RegisterGauge(
+				MetricWorkerPoolGroupQueued,
+				"Number of jobs waiting for a worker in each group of the priority worker pool.",
+				labels,
+				func() float64 { return p.read(&group.queued) },
+			),
+		)
+	}
+	return unregisters
+}
+
+func (p *priorityPool) read(v *int) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return float64(*v)
+}
@@ -0,0 +1,129 @@
+package interceptor
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rizalgowandy/cronx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityWorkerPool(t *testing.T) {
+	t.Parallel()
+
+	pool := PriorityWorkerPool(PriorityWorkerPoolConfig{
+		Groups: []PoolGroup{
+			{Name: "billing", Capacity: 1, Jobs: []string{"payBill"}},
+			{Name: "report", Capacity: 1, Jobs: []string{"sendReport"}},
+		},
+	})
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	blocking := func(context.Context, *cronx.Job) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		_ = pool(context.Background(), &cronx.Job{Name: "payBill"}, blocking)
+		close(done)
+	}()
+	<-started
+
+	// Other groups are not starved by a busy group.
+	ran := false
+	require.NoError(t, pool(context.Background(), &cronx.Job{Name: "sendReport"}, func(context.Context, *cronx.Job) error {
+		ran = true
+		return nil
+	}))
+	assert.True(t, ran)
+
+	// Jobs without a group are not limited.
+	require.NoError(t, pool(context.Background(), &cronx.Job{Name: "cleanUp"}, func(context.Context, *cronx.Job) error {
+		return nil
+	}))
+
+	// Waiting stops once the context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := pool(ctx, &cronx.Job{Name: "payBill"}, func(context.Context, *cronx.Job) error {
+		t.Error("handler must not be called")
+		return nil
+	})
+	require.Error(t, err)
+
+	close(release)
+	<-done
+	require.NoError(t, pool(context.Background(), &cronx.Job{Name: "payBill"}, blocking))
+	<-started
+}
+
+func TestPriorityPool_acquire(t *testing.T) {
+	t.Parallel()
+
+	pool := newPriorityPool(PriorityWorkerPoolConfig{
+		Groups:   []PoolGroup{{Name: "billing", Capacity: 1, Jobs: []string{"payBill"}}},
+		Overflow: 1,
+	})
+	group := pool.jobGroups["payBill"]
+	noop := func(bool) {}
+
+	first, err := pool.acquire(context.Background(), group, 0, noop)
+	require.NoError(t, err)
+	assert.False(t, first.overflow)
+	second, err := pool.acquire(context.Background(), group, 0, noop)
+	require.NoError(t, err)
+	assert.True(t, second.overflow)
+
+	// Higher priority waiter acquires the worker first.
+	order := make(chan int, 2)
+	queued := make(chan bool, 4)
+	for _, priority := range []int{1, 10} {
+		go func(priority int) {
+			w, err := pool.acquire(context.Background(), group, priority, func(q bool) { queued <- q })
+			if err == nil {
+				order <- priority
+				pool.release(w)
+			}
+		}(priority)
+		assert.True(t, <-queued)
+	}
+	assert.Equal(t, float64(2), pool.read(&group.queued))
+
+	pool.release(first)
+	assert.Equal(t, 10, <-order)
+	pool.release(second)
+	assert.Equal(t, 1, <-order)
+	assert.Equal(t, float64(0), pool.read(&group.queued))
+}
+
+func TestPriorityWorkerPoolMetrics(t *testing.T) {
+	metrics := cronx.NewMetrics()
+	_, closePool := NewPriorityWorkerPool(
+		PriorityWorkerPoolConfig{
+			Groups:   []PoolGroup{{Name: "billing", Capacity: 2, Jobs: []string{"payBill"}}},
+			Overflow: 1,
+		},
+		WithPoolName("critical"),
+		WithPoolMetrics(metrics),
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, metrics.Write(&buf))
+	assert.Contains(t, buf.String(), MetricWorkerPoolGroupCapacity+`{group="billing",pool="critical"} 2`+"\n")
+	assert.Contains(t, buf.String(), MetricWorkerPoolGroupCapacity+`{group="overflow",pool="critical"} 1`+"\n")
+	assert.Contains(t, buf.String(), MetricWorkerPoolGroupInUse+`{group="billing",pool="critical"} 0`+"\n")
+	assert.Contains(t, buf.String(), MetricWorkerPoolGroupQueued+`{group="billing",pool="critical"} 0`+"\n")
+
+	closePool()
+	buf.Reset()
+	require.NoError(t, metrics.Write(&buf))
+	assert.NotContains(t, buf.String(), MetricWorkerPoolGroupCapacity)
+	assert.NotContains(t, buf.String(), MetricWorkerPoolGroupInUse)
+	assert.NotContains(t, buf.String(), MetricWorkerPoolGroupQueued)
+}
@@ -0,0 +1,279 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redsync/redsync/v4"
+	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/rizalgowandy/gdk/pkg/logx"
+)
+
+// Default configuration.
+var (
+	defaultRateLimitGroup    = "default"
+	defaultRateLimitInterval = time.Second
+)
+
+// DistributedLeaseItf creates a distributed mutex that expires on its own.
+// *redsync.Redsync implements this interface.
+type DistributedLeaseItf interface {
+	NewMutex(name string, options ...redsync.Option) *redsync.Mutex
+}
+
+// RateLimitConfig describes the rate limit of a group of jobs.
+type RateLimitConfig struct {
+	// Group names the token bucket.
+	// Rate limits with the same group share the bucket, so they must have the same limit and interval.
+	// Default is "default".
+	Group string
+	// Jobs limits the rate limit to these job names.
+	// Empty means every job.
+	Jobs []string
+	// Limit is the number of runs allowed per interval.
+	// Non-positive value disables the rate limit.
+	Limit int
+	// Interval is the period of the limit.
+	// Default is a second.
+	Interval time.Duration
+	// Skip skips the run when the bucket is exhausted,
+	// otherwise the run waits for the next token.
+	Skip bool
+	// Lease shares the bucket across servers using the distributed lock backend.
+	// Default is an in-memory bucket shared within the process.
+	Lease DistributedLeaseItf
+}
+
+// RateLimit is a middleware that limits how often a group of jobs can run.
+// WorkerPool caps how many jobs run at the same time,
+// while RateLimit caps how many runs start per interval, e.g. to stay within a partner API quota.
+// Throttled runs are reported in the run logs, and skipped runs are recorded as SKIPPED, see cronx.NewSkipError.
+// A configuration conflicting with another rate limit of the same group fails every run, see NewRateLimit.
+// Use NewRateLimit instead to remove the bucket once it is no longer used.
+func RateLimit(cfg RateLimitConfig) cronx.Interceptor {
+	limit, _, err := NewRateLimit(cfg)
+	if err != nil {
+		return func(ctx context.Context, job *cronx.Job, handler cronx.Handler) error {
+			return errorx.E(err, errorx.Op(job.Name))
+		}
+	}
+	return limit
+}
+
+// NewRateLimit returns a RateLimit middleware,
+// and a function that removes the in-memory bucket once no rate limit of the group is used.
+// Rate limits of the same group within the process must have the same limit and interval,
+// otherwise a config error is returned.
+func NewRateLimit(cfg RateLimitConfig) (cronx.Interceptor, func(), error) {
+	if cfg.Limit <= 0 {
+		return func(ctx context.Context, job *cronx.Job, handler cronx.Handler) error {
+			return handler(ctx, job)
+		}, func() {}, nil
+	}
+	if cfg.Group == "" {
+		cfg.Group = defaultRateLimitGroup
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultRateLimitInterval
+	}
+
+	jobs := make(map[string]bool, len(cfg.Jobs))
+	for _, v := range cfg.Jobs {
+		jobs[v] = true
+	}
+
+	bucket, release, err := acquireTokenBucket(cfg.Group, cfg.Limit, cfg.Interval)
+	if err != nil {
+		return nil, nil, err
+	}
+	var limiter rateLimiter = bucket
+	if cfg.Lease != nil {
+		limiter = &leaseBucket{
+			lease:    cfg.Lease,
+			key:      cfg.Group,
+			limit:    cfg.Limit,
+			interval: cfg.Interval,
+		}
+	}
+
+	return func(ctx context.Context, job *cronx.Job, handler cronx.Handler) error {
+		if len(jobs) > 0 && !jobs[job.Name] {
+			return handler(ctx, job)
+		}
+
+		start := time.Now()
+		for {
+			ok, wait, err := limiter.take(ctx)
+			if err != nil {
+				return errorx.E(err, errorx.Op(job.Name), errorx.Fields{"rate_limit_group": cfg.Group})
+			}
+			if ok {
+				break
+			}
+
+			if cfg.Skip {
+				cronx.Logger(ctx).Warn("rate limit: run is skipped", logx.KV{"group": cfg.Group})
+				return cronx.NewSkipError(job, "rate limit exceeded", errorx.Fields{"rate_limit_group": cfg.Group})
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return errorx.E(ctx.Err(), errorx.Op(job.Name), errorx.Fields{"rate_limit_group": cfg.Group})
+			case <-timer.C:
+			}
+		}
+
+		if waited := time.Since(start); waited >= time.Millisecond {
+			cronx.Logger(ctx).Info("rate limit: run is throttled", logx.KV{
+				"group": cfg.Group,
+				"wait":  waited.String(),
+			})
+		}
+
+		return handler(ctx, job)
+	}, release, nil
+}
+
+// rateLimiter hands out the tokens of a bucket.
+type rateLimiter interface {
+	// take returns true if a token is taken,
+	// otherwise returns the estimated wait before the next token is available.
+	take(ctx context.Context) (bool, time.Duration, error)
+}
+
+var (
+	tokenBucketsMu sync.Mutex
+	tokenBuckets   = map[string]*sharedTokenBucket{}
+)
+
+// sharedTokenBucket is the in-memory bucket of a group, shared by the rate limits of the group.
+type sharedTokenBucket struct {
+	bucket   *tokenBucket
+	limit    int
+	interval time.Duration
+	refs     int
+}
+
+// acquireTokenBucket returns the in-memory bucket of the group, creating it if not exists,
+// and a function that removes the bucket once every rate limit of the group has released it.
+func acquireTokenBucket(group string, limit int, interval time.Duration) (*tokenBucket, func(), error) {
+	tokenBucketsMu.Lock()
+	defer tokenBucketsMu.Unlock()
+
+	shared, ok := tokenBuckets[group]
+	if !ok {
+		shared = &sharedTokenBucket{
+			bucket:   newTokenBucket(limit, interval),
+			limit:    limit,
+			interval: interval,
+			refs:     0,
+		}
+		tokenBuckets[group] = shared
+	}
+	if shared.limit != limit || shared.interval != interval {
+		return nil, nil, errorx.E(
+			"rate limit group is already used with a different limit or interval",
+			errorx.CodeConfig,
+			errorx.Fields{
+				"rate_limit_group": group,
+				"limit":            strconv.Itoa(shared.limit) + "/" + shared.interval.String(),
+				"requested_limit":  strconv.Itoa(limit) + "/" + interval.String(),
+			},
+		)
+	}
+	shared.refs++
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			tokenBucketsMu.Lock()
+			defer tokenBucketsMu.Unlock()
+
+			shared.refs--
+			if shared.refs == 0 && tokenBuckets[group] == shared {
+				delete(tokenBuckets, group)
+			}
+		})
+	}
+	return shared.bucket, release, nil
+}
+
+func newTokenBucket(limit int, interval time.Duration) *tokenBucket {
+	return &tokenBucket{
+		mu:       sync.Mutex{},
+		capacity: float64(limit),
+		tokens:   float64(limit),
+		every:    interval / time.Duration(limit),
+		last:     time.Now(),
+	}
+}
+
+// tokenBucket is an in-memory bucket refilled with a token every interval divided by the limit.
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	every    time.Duration
+	last     time.Time
+}
+
+func (b *tokenBucket) take(context.Context) (bool, time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.every > 0 {
+		b.tokens += float64(now.Sub(b.last)) / float64(b.every)
+	} else {
+		b.tokens = b.capacity
+	}
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	return false, time.Duration((1 - b.tokens) * float64(b.every)), nil
+}
+
+// leaseBucket is a distributed bucket where each token is a lease that expires after the interval.
+// At most limit runs across servers can hold a lease at the same time.
+// A lease is never released before it expires, otherwise more runs than the limit could start within the interval.
+type leaseBucket struct {
+	lease    DistributedLeaseItf
+	key      string
+	limit    int
+	interval time.Duration
+}
+
+func (b *leaseBucket) take(ctx context.Context) (bool, time.Duration, error) {
+	for i := 0; i < b.limit; i++ {
+		mutex := b.lease.NewMutex(
+			"cronx:rate_limit:"+b.key+":"+strconv.Itoa(i),
+			redsync.WithExpiry(b.interval),
+			redsync.WithTries(1),
+		)
+
+		err := mutex.TryLockContext(ctx)
+		if err == nil {
+			return true, 0, nil
+		}
+
+		var redisErr *redsync.RedisError
+		if errors.As(err, &redisErr) {
+			return false, 0, errorx.E(err)
+		}
+	}
+
+	return false, b.interval / time.Duration(b.limit), nil
+}
@@ -0,0 +1,267 @@
+package interceptor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redsync/redsync/v4"
+	"github.com/go-redsync/redsync/v4/redis"
+	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryPool is an in-memory redis pool that only supports what the redsync mutex needs.
+type memoryPool struct {
+	mu   sync.Mutex
+	keys map[string]time.Time
+}
+
+func (p *memoryPool) Get(context.Context) (redis.Conn, error) {
+	return &memoryConn{pool: p}, nil
+}
+
+type memoryConn struct {
+	redis.Conn
+	pool *memoryPool
+}
+
+func (c *memoryConn) SetNX(name string, _ string, expiry time.Duration) (bool, error) {
+	c.pool.mu.Lock()
+	defer c.pool.mu.Unlock()
+
+	if until, ok := c.pool.keys[name]; ok && time.Now().Before(until) {
+		return false, nil
+	}
+	c.pool.keys[name] = time.Now().Add(expiry)
+	return true, nil
+}
+
+func (c *memoryConn) Eval(*redis.Script, ...interface{}) (interface{}, error) {
+	return int64(0), nil
+}
+
+func (c *memoryConn) Close() error {
+	return nil
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	runs := 0
+	handler := func(context.Context, *cronx.Job) error {
+		runs++
+		return nil
+	}
+	ctx := cronx.SetLogger(context.Background(), cronx.NewRunLogger(1024))
+
+	limit := RateLimit(RateLimitConfig{
+		Group:    "TestRateLimit",
+		Jobs:     []string{"callPartner"},
+		Limit:    2,
+		Interval: time.Hour,
+		Skip:     true,
+	})
+	job := &cronx.Job{Name: "callPartner"}
+
+	require.NoError(t, limit(ctx, job, handler))
+	require.NoError(t, limit(ctx, job, handler))
+	err := limit(ctx, job, handler)
+	require.Error(t, err)
+	assert.True(t, errorx.Is(err, errorx.CodeConflict))
+	assert.Equal(t, 2, runs)
+	require.Len(t, cronx.Logger(ctx).Logs().Lines, 1)
+	assert.Equal(t, "rate limit: run is skipped", cronx.Logger(ctx).Logs().Lines[0].Message)
+
+	// Other jobs are not limited.
+	require.NoError(t, limit(ctx, &cronx.Job{Name: "sendInvoice"}, handler))
+	assert.Equal(t, 3, runs)
+
+	// Same group shares the same bucket.
+	shared := RateLimit(RateLimitConfig{Group: "TestRateLimit", Limit: 2, Interval: time.Hour, Skip: true})
+	err = shared(ctx, job, handler)
+	require.Error(t, err)
+	assert.True(t, errorx.Is(err, errorx.CodeConflict))
+
+	// A different limit of the same group fails every run.
+	other := RateLimit(RateLimitConfig{Group: "TestRateLimit", Limit: 10, Interval: time.Hour, Skip: true})
+	err = other(ctx, job, handler)
+	require.Error(t, err)
+	assert.True(t, errorx.Is(err, errorx.CodeConfig))
+	assert.Equal(t, 3, runs)
+}
+
+func TestNewRateLimit(t *testing.T) {
+	t.Parallel()
+
+	handler := func(context.Context, *cronx.Job) error { return nil }
+	job := &cronx.Job{Name: "callPartner"}
+	cfg := RateLimitConfig{Group: "TestNewRateLimit", Limit: 1, Interval: time.Hour, Skip: true}
+
+	first, releaseFirst, err := NewRateLimit(cfg)
+	require.NoError(t, err)
+	second, releaseSecond, err := NewRateLimit(cfg)
+	require.NoError(t, err)
+	require.NoError(t, first(context.Background(), job, handler))
+	require.Error(t, second(context.Background(), job, handler))
+
+	// A conflicting configuration is rejected.
+	_, _, err = NewRateLimit(RateLimitConfig{Group: cfg.Group, Limit: 1, Interval: time.Minute})
+	require.Error(t, err)
+	assert.True(t, errorx.Is(err, errorx.CodeConfig))
+
+	// The bucket is removed once every rate limit of the group has released it.
+	releaseFirst()
+	releaseFirst()
+	tokenBucketsMu.Lock()
+	assert.Contains(t, tokenBuckets, cfg.Group)
+	tokenBucketsMu.Unlock()
+
+	releaseSecond()
+	tokenBucketsMu.Lock()
+	assert.NotContains(t, tokenBuckets, cfg.Group)
+	tokenBucketsMu.Unlock()
+
+	// Then the group can be configured again.
+	third, releaseThird, err := NewRateLimit(RateLimitConfig{Group: cfg.Group, Limit: 1, Interval: time.Minute, Skip: true})
+	require.NoError(t, err)
+	defer releaseThird()
+	require.NoError(t, third(context.Background(), job, handler))
+}
+
+func TestRateLimitSkipped(t *testing.T) {
+	t.Parallel()
+
+	manager := cronx.NewManager(
+		cronx.WithAutoStartDisabled(),
+		cronx.WithCircuitBreaker(1, time.Hour),
+		cronx.WithAlertFailureThreshold(1),
+		cronx.WithInterceptor(RateLimit(RateLimitConfig{
+			Group:    "TestRateLimitSkipped",
+			Limit:    1,
+			Interval: time.Hour,
+			Skip:     true,
+		})),
+	)
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "callPartner", func(context.Context) error { return nil }))
+	job := manager.GetEntries()[0].Job.(*cronx.Job)
+
+	job.Run()
+	assert.Equal(t, cronx.StatusCodeSuccess, job.Status)
+
+	// A throttled run is neither a failure nor counted by the circuit breaker.
+	for i := 0; i < 3; i++ {
+		job.Run()
+		assert.Equal(t, cronx.StatusCodeSkipped, job.Status)
+		assert.Equal(t, "rate limit exceeded", job.Error)
+		assert.Zero(t, job.ConsecutiveFailures)
+		assert.Equal(t, cronx.BreakerStateClosed, job.Breaker)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	t.Parallel()
+
+	runs := 0
+	handler := func(context.Context, *cronx.Job) error {
+		runs++
+		return nil
+	}
+	ctx := cronx.SetLogger(context.Background(), cronx.NewRunLogger(1024))
+	job := &cronx.Job{Name: "callPartner"}
+
+	limit := RateLimit(RateLimitConfig{
+		Group:    "TestRateLimitWait",
+		Limit:    1,
+		Interval: 20 * time.Millisecond,
+	})
+	require.NoError(t, limit(ctx, job, handler))
+	require.NoError(t, limit(ctx, job, handler))
+	assert.Equal(t, 2, runs)
+	require.Len(t, cronx.Logger(ctx).Logs().Lines, 1)
+	assert.Equal(t, "rate limit: run is throttled", cronx.Logger(ctx).Logs().Lines[0].Message)
+
+	// Waiting stops once the context is done.
+	limit = RateLimit(RateLimitConfig{
+		Group:    "TestRateLimitWaitCanceled",
+		Limit:    1,
+		Interval: time.Hour,
+	})
+	require.NoError(t, limit(ctx, job, handler))
+	canceled, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.Error(t, limit(canceled, job, handler))
+	assert.Equal(t, 3, runs)
+
+	// Non-positive limit disables the rate limit.
+	limit = RateLimit(RateLimitConfig{})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limit(ctx, job, handler))
+	}
+}
+
+func TestRateLimitLease(t *testing.T) {
+	t.Parallel()
+
+	pool := &memoryPool{keys: map[string]time.Time{}}
+	runs := 0
+	handler := func(context.Context, *cronx.Job) error {
+		runs++
+		return nil
+	}
+	job := &cronx.Job{Name: "callPartner"}
+
+	// Each server has its own interceptor, but the leases are shared.
+	for i := 0; i < 2; i++ {
+		limit := RateLimit(RateLimitConfig{
+			Group:    "TestRateLimitLease",
+			Limit:    3,
+			Interval: time.Hour,
+			Skip:     true,
+			Lease:    redsync.New(pool),
+		})
+		for j := 0; j < 2; j++ {
+			_ = limit(context.Background(), job, handler)
+		}
+	}
+	assert.Equal(t, 3, runs)
+
+	// Every lease of the group expires after the interval.
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	require.Len(t, pool.keys, 3)
+	for name, until := range pool.keys {
+		assert.Contains(t, name, "cronx:rate_limit:TestRateLimitLease:")
+		assert.WithinDuration(t, time.Now().Add(time.Hour), until, time.Minute)
+	}
+}
+
+func TestRateLimitLeaseExpired(t *testing.T) {
+	t.Parallel()
+
+	pool := &memoryPool{keys: map[string]time.Time{}}
+	runs := 0
+	handler := func(context.Context, *cronx.Job) error {
+		runs++
+		return nil
+	}
+	job := &cronx.Job{Name: "callPartner"}
+
+	limit := RateLimit(RateLimitConfig{
+		Group:    "TestRateLimitLeaseExpired",
+		Limit:    1,
+		Interval: 20 * time.Millisecond,
+		Skip:     true,
+		Lease:    redsync.New(pool),
+	})
+	require.NoError(t, limit(context.Background(), job, handler))
+	require.Error(t, limit(context.Background(), job, handler))
+
+	// A new token is available once the lease has expired.
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, limit(context.Background(), job, handler))
+	assert.Equal(t, 2, runs)
+}
@@ -2,6 +2,7 @@ package interceptor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime/debug"
 
@@ -10,21 +11,18 @@ import (
 	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
 	"github.com/rizalgowandy/gdk/pkg/logx"
 	"github.com/rizalgowandy/gdk/pkg/netx"
-	"github.com/rizalgowandy/gdk/pkg/stack"
 	"github.com/rizalgowandy/gdk/pkg/tags"
 )
 
 // Recover is a middleware that recovers server from panic.
-// Recover also dumps stack trace on panic occurrence.
+// Recover also dumps stack trace on panic occurrence,
+// and returns the panic as an error, so the run is recorded as PANIC.
 func Recover() cronx.Interceptor {
-	return func(ctx context.Context, job *cronx.Job, handler cronx.Handler) error {
+	return func(ctx context.Context, job *cronx.Job, handler cronx.Handler) (err error) {
 		defer func() {
-			if err := recover(); err != nil {
-				fields := errorx.Fields{
-					"stack": stack.ToArr(stack.Trim(debug.Stack())),
-					"job":   job,
-				}
-				logx.ERR(ctx, errorx.E(err, fields), "recovered")
+			if r := recover(); r != nil {
+				err = cronx.NewPanicError(job, r, debug.Stack())
+				logx.ERR(ctx, err, "recovered from panic")
 			}
 		}()
 
@@ -48,16 +46,12 @@ func RecoverWithAlert(
 	return func(ctx context.Context, job *cronx.Job, handler cronx.Handler) (err error) {
 		defer func() {
 			if r := recover(); r != nil {
-				stackTrace := stack.ToArr(stack.Trim(debug.Stack()))
+				err = cronx.NewPanicError(job, r, debug.Stack())
 
-				err = errorx.E(
-					"there is a panic",
-					errorx.Op(job.Name),
-					errorx.CodeInternal,
-					errorx.Fields{
-						tags.StackTrace: stackTrace,
-						tags.Panic:      r,
-					})
+				var e *errorx.Error
+				errors.As(err, &e)
+				stackTrace, _ := e.Fields[tags.StackTrace].([]string)
+				r = e.Fields[tags.Panic]
 
 				// Create a panic log.
 				logx.ERR(
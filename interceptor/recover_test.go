@@ -1,9 +1,14 @@
 package interceptor
 
 import (
+	"context"
 	"testing"
 
+	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/rizalgowandy/gdk/pkg/tags"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRecover(t *testing.T) {
@@ -21,3 +26,16 @@ func TestRecover(t *testing.T) {
 		})
 	}
 }
+
+func TestRecoverReturnsPanic(t *testing.T) {
+	job := cronx.NewJob(cronx.NewManager(cronx.WithAutoStartDisabled()), cronx.Func(nil), 1, 1)
+
+	err := Recover()(context.Background(), job, func(context.Context, *cronx.Job) error {
+		panic("boom")
+	})
+
+	var e *errorx.Error
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, "boom", e.Fields[tags.Panic])
+	assert.NotEmpty(t, e.Fields[tags.StackTrace])
+}
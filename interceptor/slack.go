@@ -3,6 +3,8 @@ package interceptor
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/rizalgowandy/cronx"
 	"github.com/rizalgowandy/gdk/pkg/env"
@@ -16,37 +18,116 @@ type SlackClientItf interface {
 }
 
 // NotifySlack is a middleware that send alert to slack on error.
+// Repeated errors of the same job are sent once per cronx.DefaultAlertCooldown,
+// and a recovery message is sent once the job succeeds again.
 func NotifySlack(
 	serviceName string,
 	mode string,
 	sc SlackClientItf,
+) cronx.Interceptor {
+	return NotifySlackWithCooldown(serviceName, mode, sc, cronx.DefaultAlertCooldown)
+}
+
+// NotifySlackWithCooldown is a middleware that send alert to slack on error.
+// Repeated errors of the same job are sent once per cooldown,
+// and a recovery message is sent once the job succeeds again.
+func NotifySlackWithCooldown(
+	serviceName string,
+	mode string,
+	sc SlackClientItf,
+	cooldown time.Duration,
 ) cronx.Interceptor {
 	var (
 		currentEnv   = env.GetCurrent()
 		isProduction = env.IsProduction()
 		ipAddress    = netx.GetIPv4()
+		gate         = cronx.NewAlertGate(cooldown)
+		failing      = sync.Map{}
 	)
 
 	return func(ctx context.Context, job *cronx.Job, handler cronx.Handler) (err error) {
 		err = handler(ctx, job)
-		if err != nil {
-			// No need to notify on expected error.
-			if e, ok := err.(*errorx.Error); ok {
-				if e.MetricStatus == errorx.MetricStatusExpectedErr {
-					return err
-				}
+		key := job.Key()
+
+		if err == nil {
+			// Send slack recovery message if the previous run has been alerted.
+			if _, ok := failing.LoadAndDelete(key); ok {
+				gate.Reset(key)
+
+				msg := fmt.Sprintf("[%s] *recovered*", currentEnv)
+				msg += fmt.Sprintf(" | `host: %s` | `app: %s-%s`", ipAddress, serviceName, mode)
+				msg += fmt.Sprintf("\n*Job:* `%s`", job.Name)
+				sc.Send(ctx, msg, false)
+			}
+			return nil
+		}
+
+		// No need to notify on expected error.
+		if e, ok := err.(*errorx.Error); ok {
+			if e.MetricStatus == errorx.MetricStatusExpectedErr {
+				return err
 			}
+		}
 
-			// Send slack alert.
-			msg := fmt.Sprintf("[%s] *%v*", currentEnv, err.Error())
-			msg += fmt.Sprintf(" | `host: %s` | `app: %s-%s`", ipAddress, serviceName, mode)
-			msg += fmt.Sprintf("\n*Job:* `%s`", job.Name)
-			msg += fmt.Sprintf(
-				"\n*Request ID:* `%s` _(search log file using this id)_",
-				logx.GetRequestID(ctx),
-			)
-			sc.Send(ctx, msg, isProduction)
+		// No need to notify the same error again during cooldown.
+		failing.Store(key, true)
+		if !gate.Allow(key, cronx.AlertEventFailure, time.Now()) {
+			return err
 		}
+
+		// Send slack alert.
+		msg := fmt.Sprintf("[%s] *%v*", currentEnv, err.Error())
+		msg += fmt.Sprintf(" | `host: %s` | `app: %s-%s`", ipAddress, serviceName, mode)
+		msg += fmt.Sprintf("\n*Job:* `%s`", job.Name)
+		msg += fmt.Sprintf(
+			"\n*Request ID:* `%s` _(search log file using this id)_",
+			logx.GetRequestID(ctx),
+		)
+		sc.Send(ctx, msg, isProduction)
+
 		return err
 	}
 }
+
+// NewSlackAlerter creates an alerter that sends every alert to Slack.
+// Use it with cronx.WithAlerter.
+func NewSlackAlerter(serviceName, mode string, sc SlackClientItf) *SlackAlerter {
+	return &SlackAlerter{
+		serviceName:  serviceName,
+		mode:         mode,
+		sc:           sc,
+		currentEnv:   env.GetCurrent(),
+		isProduction: env.IsProduction(),
+		ipAddress:    netx.GetIPv4(),
+	}
+}
+
+// SlackAlerter sends alerts to Slack.
+type SlackAlerter struct {
+	serviceName  string
+	mode         string
+	sc           SlackClientItf
+	currentEnv   string
+	isProduction bool
+	ipAddress    string
+}
+
+func (s *SlackAlerter) Notify(ctx context.Context, alert *cronx.Alert) {
+	msg := fmt.Sprintf("[%s] *%s*", s.currentEnv, alert.Message())
+	msg += fmt.Sprintf(" | `host: %s` | `app: %s-%s`", s.ipAddress, s.serviceName, s.mode)
+	msg += fmt.Sprintf("\n*Event:* `%s`", alert.Event)
+	if alert.Err != nil {
+		msg += fmt.Sprintf("\n*Error:* `%v`", alert.Err)
+	}
+	if alert.ConsecutiveFailures > 0 {
+		msg += fmt.Sprintf("\n*Consecutive Failures:* `%d`", alert.ConsecutiveFailures)
+	}
+	msg += fmt.Sprintf(
+		"\n*Request ID:* `%s` _(search log file using this id)_",
+		logx.GetRequestID(ctx),
+	)
+
+	// No need to mention anyone when the job has recovered.
+	mention := s.isProduction && alert.Event != cronx.AlertEventRecovery
+	s.sc.Send(ctx, msg, mention)
+}
@@ -1,11 +1,26 @@
 package interceptor
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/rizalgowandy/cronx"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+type slackRecorder struct {
+	messages []string
+	mentions []bool
+}
+
+func (s *slackRecorder) Send(_ context.Context, msg string, mention bool) {
+	s.messages = append(s.messages, msg)
+	s.mentions = append(s.mentions, mention)
+}
+
 func TestNotifySlack(t *testing.T) {
 	type args struct {
 		serviceName string
@@ -27,3 +42,45 @@ func TestNotifySlack(t *testing.T) {
 		})
 	}
 }
+
+func TestNotifySlackWithCooldown(t *testing.T) {
+	sc := &slackRecorder{}
+	notify := NotifySlackWithCooldown("service", "cron", sc, time.Hour)
+	job := &cronx.Job{Name: "payBill"}
+
+	var runErr error
+	handler := func(context.Context, *cronx.Job) error { return runErr }
+
+	runErr = errors.New("error")
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, runErr, notify(context.Background(), job, handler))
+	}
+	require.Len(t, sc.messages, 1)
+	assert.Contains(t, sc.messages[0], "error")
+
+	runErr = nil
+	assert.NoError(t, notify(context.Background(), job, handler))
+	assert.NoError(t, notify(context.Background(), job, handler))
+	require.Len(t, sc.messages, 2)
+	assert.Contains(t, sc.messages[1], "recovered")
+	assert.False(t, sc.mentions[1])
+
+	runErr = errors.New("error")
+	assert.Equal(t, runErr, notify(context.Background(), job, handler))
+	assert.Len(t, sc.messages, 3)
+}
+
+func TestSlackAlerter(t *testing.T) {
+	sc := &slackRecorder{}
+	alerter := NewSlackAlerter("service", "cron", sc)
+
+	alerter.Notify(context.Background(), &cronx.Alert{
+		Event:               cronx.AlertEventConsecutiveFailure,
+		Job:                 &cronx.Job{Name: "payBill"},
+		Err:                 errors.New("error"),
+		ConsecutiveFailures: 3,
+	})
+	require.Len(t, sc.messages, 1)
+	assert.Contains(t, sc.messages[0], "Operation cron payBill has failed 3 times in a row")
+	assert.Contains(t, sc.messages[0], "`CONSECUTIVE_FAILURE`")
+}
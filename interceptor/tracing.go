@@ -0,0 +1,106 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+
+	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name of the spans created by Tracing.
+const TracerName = "github.com/rizalgowandy/cronx"
+
+// List of span attributes set by Tracing.
+const (
+	AttrJobName           = attribute.Key("cronx.job.name")
+	AttrJobEntryID        = attribute.Key("cronx.job.entry_id")
+	AttrJobWave           = attribute.Key("cronx.job.wave")
+	AttrJobTotalWave      = attribute.Key("cronx.job.total_wave")
+	AttrJobAttempt        = attribute.Key("cronx.job.attempt")
+	AttrErrorCode         = attribute.Key("cronx.error.code")
+	AttrErrorOpTraces     = attribute.Key("cronx.error.op_traces")
+	AttrErrorMessage      = attribute.Key("cronx.error.message")
+	AttrErrorMetricStatus = attribute.Key("cronx.error.metric_status")
+)
+
+// Tracing is a middleware that starts a span for every job run.
+// The span is stored inside the context, so downstream calls join the same trace.
+// The attempt is 1 for the first run after a success, and increases on every failed run in a row.
+// A panicking run is recorded on the span, then the panic is passed on to the job
+// with the stack trace of the original panic.
+// The exporter is configured through the tracer provider,
+// if the provider is nil, the global tracer provider is used.
+func Tracing(tp trace.TracerProvider) cronx.Interceptor {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	tracer := tp.Tracer(TracerName)
+
+	return func(ctx context.Context, job *cronx.Job, handler cronx.Handler) error {
+		ctx, span := tracer.Start(
+			ctx,
+			job.Name,
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithAttributes(
+				AttrJobName.String(job.Name),
+				AttrJobEntryID.Int64(int64(job.EntryID)),
+				AttrJobWave.Int64(job.Wave),
+				AttrJobTotalWave.Int64(job.TotalWave),
+				AttrJobAttempt.Int64(job.ConsecutiveFailures+1),
+			),
+		)
+		defer span.End()
+		defer func() {
+			if r := recover(); r != nil {
+				// Pass on the panic error, so the stack trace still starts at the original panic.
+				err := cronx.NewPanicError(job, r, debug.Stack())
+				span.RecordError(err, trace.WithAttributes(ErrorAttributes(err)...))
+				span.SetStatus(codes.Error, err.Error())
+				panic(err)
+			}
+		}()
+
+		err := handler(ctx, job)
+		if err != nil {
+			span.RecordError(err, trace.WithAttributes(ErrorAttributes(err)...))
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+
+		span.SetStatus(codes.Ok, "")
+		return nil
+	}
+}
+
+// ErrorAttributes returns span attributes describing the error, including an error wrapped by it.
+func ErrorAttributes(err error) []attribute.KeyValue {
+	var e *errorx.Error
+	if !errors.As(err, &e) {
+		return nil
+	}
+
+	var attrs []attribute.KeyValue
+	if e.Code != "" {
+		attrs = append(attrs, AttrErrorCode.String(string(e.Code)))
+	}
+	if e.Message != "" {
+		attrs = append(attrs, AttrErrorMessage.String(string(e.Message)))
+	}
+	if e.MetricStatus != "" {
+		attrs = append(attrs, AttrErrorMetricStatus.String(string(e.MetricStatus)))
+	}
+	if len(e.OpTraces) > 0 {
+		ops := make([]string, len(e.OpTraces))
+		for k, v := range e.OpTraces {
+			ops[k] = string(v)
+		}
+		attrs = append(attrs, AttrErrorOpTraces.StringSlice(ops))
+	}
+	return attrs
+}
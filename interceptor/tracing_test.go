@@ -0,0 +1,116 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/cronx/internal/cronxtest"
+	"github.com/rizalgowandy/cronx/storage"
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/rizalgowandy/gdk/pkg/tags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	tests := []struct {
+		name       string
+		handlerErr error
+		wantStatus codes.Code
+		wantEvents int
+	}{
+		{
+			name:       "Success",
+			handlerErr: nil,
+			wantStatus: codes.Ok,
+			wantEvents: 0,
+		},
+		{
+			name:       "Error",
+			handlerErr: errorx.E("error", errorx.CodeInternal),
+			wantStatus: codes.Error,
+			wantEvents: 1,
+		},
+		{
+			name:       "Wrapped error",
+			handlerErr: fmt.Errorf("pay bill: %w", errorx.E("error", errorx.CodeInternal)),
+			wantStatus: codes.Error,
+			wantEvents: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+			job := &cronx.Job{
+				JobMetadata: cronx.JobMetadata{EntryID: 7, Wave: 1, TotalWave: 2},
+				Name:        "payBill",
+
+				ConsecutiveFailures: 2,
+			}
+			var spanCtx trace.SpanContext
+			err := Tracing(tp)(context.Background(), job, func(ctx context.Context, job *cronx.Job) error {
+				spanCtx = trace.SpanContextFromContext(ctx)
+				return tt.handlerErr
+			})
+			assert.Equal(t, tt.handlerErr, err)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "payBill", spans[0].Name)
+			assert.Equal(t, spans[0].SpanContext.SpanID(), spanCtx.SpanID())
+			assert.Equal(t, tt.wantStatus, spans[0].Status.Code)
+			assert.Contains(t, spans[0].Attributes, AttrJobEntryID.Int64(7))
+			assert.Contains(t, spans[0].Attributes, AttrJobWave.Int64(1))
+			assert.Contains(t, spans[0].Attributes, AttrJobAttempt.Int64(3))
+			require.Len(t, spans[0].Events, tt.wantEvents)
+			if tt.wantEvents > 0 {
+				assert.Contains(t, spans[0].Events[0].Attributes, AttrErrorCode.String(string(errorx.CodeInternal)))
+				attrs := map[string]bool{}
+				for _, v := range spans[0].Events[0].Attributes {
+					attrs[string(v.Key)] = true
+				}
+				assert.True(t, attrs[string(AttrErrorOpTraces)])
+			}
+		})
+	}
+}
+
+func TestTracing_Panic(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	store := &cronxtest.MemoryStorage{}
+	manager := cronx.NewManager(
+		cronx.WithAutoStartDisabled(),
+		cronx.WithStorage(store),
+		cronx.WithInterceptor(Tracing(tp)),
+	)
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", func(context.Context) error {
+		panic("nil map")
+	}))
+	job := manager.GetEntries()[0].Job.(*cronx.Job)
+	job.Run()
+
+	// The panic is still recovered by the job, with the stack trace of the original panic.
+	assert.Equal(t, cronx.StatusCodePanic, job.Status)
+	histories, err := store.ReadHistories(context.Background(), &storage.HistoryFilter{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, histories, 1)
+	require.NotEmpty(t, histories[0].Error.Stack)
+	assert.Contains(t, histories[0].Error.Stack[0], "TestTracing_Panic")
+	assert.Equal(t, "nil map", histories[0].Error.Fields[tags.Panic])
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	require.NotEmpty(t, spans[0].Events)
+	assert.Contains(t, spans[0].Events[0].Attributes, AttrErrorCode.String(string(errorx.CodeInternal)))
+}
@@ -2,26 +2,172 @@ package interceptor
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
 )
 
 // Default configuration.
-var defaultWorkerPoolSize = 1000
+var (
+	defaultWorkerPoolSize = 1000
+	defaultPoolName       = "default"
+)
+
+// List of metric names exposed by the worker pool.
+const (
+	MetricWorkerPoolCapacity = "cronx_worker_pool_capacity"
+	MetricWorkerPoolInUse    = "cronx_worker_pool_in_use"
+)
+
+// PoolOption customizes the worker pool.
+type PoolOption func(*poolConfig)
+
+type poolConfig struct {
+	name    string
+	metrics *cronx.Metrics
+}
+
+func newPoolConfig(opts []PoolOption) poolConfig {
+	cfg := poolConfig{
+		name:    defaultPoolName,
+		metrics: nil,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// poolGauges registers the gauges of a pool once,
+// either on creation with the metrics of WithPoolMetrics,
+// or on the first run with the metrics of the manager running the job.
+type poolGauges struct {
+	once        sync.Once
+	mu          sync.Mutex
+	closed      bool
+	register    func(metrics *cronx.Metrics) []func()
+	unregisters []func()
+}
+
+func newPoolGauges(cfg poolConfig, register func(metrics *cronx.Metrics) []func()) *poolGauges {
+	g := &poolGauges{register: register}
+	if cfg.metrics != nil {
+		g.attach(cfg.metrics)
+	}
+	return g
+}
+
+// attach registers the gauges to the metrics, unless they have been registered or closed.
+func (g *poolGauges) attach(metrics *cronx.Metrics) {
+	if metrics == nil {
+		return
+	}
+
+	g.once.Do(func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if !g.closed {
+			g.unregisters = g.register(metrics)
+		}
+	})
+}
+
+// close unregisters the gauges, and prevents them from being registered later.
+func (g *poolGauges) close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.closed = true
+	for _, unregister := range g.unregisters {
+		unregister()
+	}
+	g.unregisters = nil
+}
+
+// WithPoolName sets the name of the pool in the metrics.
+// Pools in the same metrics must have different names, otherwise the last one replaces the others.
+// Default is "default".
+func WithPoolName(name string) PoolOption {
+	return func(c *poolConfig) {
+		if name != "" {
+			c.name = name
+		}
+	}
+}
+
+// WithPoolMetrics sets where the pool saturation is exposed.
+// Default is the metrics of the manager running the pool, from the first run onwards.
+func WithPoolMetrics(metrics *cronx.Metrics) PoolOption {
+	return func(c *poolConfig) {
+		if metrics != nil {
+			c.metrics = metrics
+		}
+	}
+}
 
 // WorkerPool is a middleware that limit total cron that can run a time.
 // Program is running on a server with finite amount of resources such as CPU and RAM.
 // By limiting the total number of jobs that can be run the same time,
 // we protect the server from overloading.
-func WorkerPool(size int) cronx.Interceptor {
+// While waiting for a worker, the job status is QUEUED, and the wait is recorded separately from the latency.
+// The pool saturation is exposed through the manager metrics, or the metrics of WithPoolMetrics,
+// labelled by the name of WithPoolName.
+// Use NewWorkerPool instead to remove the pool from the metrics once it is no longer used.
+func WorkerPool(size int, opts ...PoolOption) cronx.Interceptor {
+	pool, _ := NewWorkerPool(size, opts...)
+	return pool
+}
+
+// NewWorkerPool returns a WorkerPool middleware,
+// and a function that removes the pool from the metrics once the pool is no longer used.
+func NewWorkerPool(size int, opts ...PoolOption) (cronx.Interceptor, func()) {
 	if size <= 0 {
 		size = defaultWorkerPoolSize
 	}
+	cfg := newPoolConfig(opts)
+
 	pool := make(chan struct{}, size)
 
+	labels := map[string]string{"pool": cfg.name}
+	gauges := newPoolGauges(cfg, func(metrics *cronx.Metrics) []func() {
+		return []func(){
+			metrics.RegisterGauge(
+				MetricWorkerPoolCapacity,
+				"Number of workers in the worker pool.",
+				labels,
+				func() float64 { return float64(cap(pool)) },
+			),
+			metrics.RegisterGauge(
+				MetricWorkerPoolInUse,
+				"Number of workers in the worker pool currently running a job.",
+				labels,
+				func() float64 { return float64(len(pool)) },
+			),
+		}
+	})
+
 	return func(ctx context.Context, job *cronx.Job, handler cronx.Handler) error {
-		// Wait for worker to be available.
-		pool <- struct{}{}
+		gauges.attach(job.Metrics())
+
+		// Wait for worker to be available, unless the run is canceled or timed out.
+		start := time.Now()
+		select {
+		case pool <- struct{}{}:
+		default:
+			job.SetQueued(true)
+			select {
+			case pool <- struct{}{}:
+				job.SetQueued(false)
+			case <-ctx.Done():
+				job.SetQueued(false)
+				job.RecordQueueWait(time.Since(start))
+				return errorx.E(ctx.Err(), errorx.Op(job.Name), errorx.Fields{"pool_size": size})
+			}
+		}
+		job.RecordQueueWait(time.Since(start))
 
 		// Release the worker.
 		defer func() {
@@ -29,7 +175,7 @@ func WorkerPool(size int) cronx.Interceptor {
 		}()
 
 		return handler(ctx, job)
-	}
+	}, gauges.close
 }
 
 // DefaultWorkerPool returns a WorkerPool middleware with default configuration.
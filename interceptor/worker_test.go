@@ -1,9 +1,14 @@
 package interceptor
 
 import (
+	"bytes"
+	"context"
 	"testing"
+	"time"
 
+	"github.com/rizalgowandy/cronx"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWorkerPool(t *testing.T) {
@@ -48,3 +53,82 @@ func TestDefaultWorkerPool(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkerPoolMetrics(t *testing.T) {
+	metrics := cronx.NewMetrics()
+	got, closePool := NewWorkerPool(3, WithPoolName("billing"), WithPoolMetrics(metrics))
+	assert.NotNil(t, got)
+
+	var buf bytes.Buffer
+	assert.NoError(t, metrics.Write(&buf))
+	assert.Contains(t, buf.String(), "# TYPE "+MetricWorkerPoolCapacity+" gauge")
+	assert.Contains(t, buf.String(), MetricWorkerPoolCapacity+`{pool="billing"} 3`+"\n")
+	assert.Contains(t, buf.String(), MetricWorkerPoolInUse+`{pool="billing"} 0`+"\n")
+
+	closePool()
+	buf.Reset()
+	assert.NoError(t, metrics.Write(&buf))
+	assert.NotContains(t, buf.String(), MetricWorkerPoolCapacity)
+	assert.NotContains(t, buf.String(), MetricWorkerPoolInUse)
+}
+
+func TestWorkerPoolManagerMetrics(t *testing.T) {
+	// Every manager exposes its own pool, once the pool has run a job.
+	var managers []*cronx.Manager
+	for _, size := range []int{2, 3} {
+		manager := cronx.NewManager(
+			cronx.WithAutoStartDisabled(),
+			cronx.WithInterceptor(WorkerPool(size, WithPoolName("billing"))),
+		)
+		require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", func(context.Context) error {
+			return nil
+		}))
+		managers = append(managers, manager)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, managers[0].WriteMetrics(&buf))
+	assert.NotContains(t, buf.String(), MetricWorkerPoolCapacity)
+
+	for _, manager := range managers {
+		manager.GetEntries()[0].Job.(*cronx.Job).Run()
+	}
+	for k, want := range []string{"2", "3"} {
+		buf.Reset()
+		require.NoError(t, managers[k].WriteMetrics(&buf))
+		assert.Contains(t, buf.String(), MetricWorkerPoolCapacity+`{pool="billing"} `+want+"\n")
+	}
+}
+
+func TestWorkerPoolCanceled(t *testing.T) {
+	pool := WorkerPool(1)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		_ = pool(context.Background(), &cronx.Job{}, func(context.Context, *cronx.Job) error {
+			close(started)
+			<-release
+			return nil
+		})
+		close(done)
+	}()
+	<-started
+
+	// Waiting stops once the context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	job := &cronx.Job{}
+	err := pool(ctx, job, func(context.Context, *cronx.Job) error {
+		t.Error("handler must not be called")
+		return nil
+	})
+	require.Error(t, err)
+
+	close(release)
+	<-done
+	require.NoError(t, pool(context.Background(), job, func(context.Context, *cronx.Job) error {
+		return nil
+	}))
+}
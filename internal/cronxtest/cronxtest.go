@@ -0,0 +1,86 @@
+// Package cronxtest provides the fixtures shared by the tests of the packages using the server of cronx.
+package cronxtest
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/rizalgowandy/cronx"
+	"github.com/rizalgowandy/cronx/storage"
+	"github.com/stretchr/testify/require"
+)
+
+// MemoryStorage keeps the histories in memory, sorted by the newest id.
+type MemoryStorage struct {
+	mu        sync.Mutex
+	histories []storage.History
+}
+
+func (m *MemoryStorage) WriteHistory(_ context.Context, req *storage.History) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	req.ID = int64(len(m.histories) + 1)
+	m.histories = append([]storage.History{*req}, m.histories...)
+	return nil
+}
+
+func (m *MemoryStorage) UpdateHistory(_ context.Context, req *storage.History) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k := range m.histories {
+		if m.histories[k].ID == req.ID {
+			m.histories[k] = *req
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStorage) AbandonHistories(context.Context, *storage.AbandonFilter) (int64, error) {
+	return 0, nil
+}
+
+func (m *MemoryStorage) ReadHistories(_ context.Context, req *storage.HistoryFilter) ([]storage.History, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var res []storage.History
+	for _, v := range m.histories {
+		if req.StartingAfter != nil && v.ID >= *req.StartingAfter {
+			continue
+		}
+		if req.EndingBefore != nil && v.ID <= *req.EndingBefore {
+			continue
+		}
+		if len(res) == req.Limit {
+			break
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+// NewServer creates a server on /admin/cron authenticated by the tokens,
+// with a job named payBill that has run the number of times, and a job named sendInvoice that has never run.
+// The server is closed once the test has finished.
+func NewServer(t *testing.T, runs int, tokens map[string]cronx.Role) (*httptest.Server, *cronx.Manager) {
+	t.Helper()
+
+	manager := cronx.NewManager(cronx.WithAutoStartDisabled(), cronx.WithStorage(&MemoryStorage{}))
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", func(context.Context) error { return nil }))
+	require.NoError(t, manager.ScheduleFunc("@every 1h", "sendInvoice", func(context.Context) error { return nil }))
+	for range runs {
+		manager.GetEntries()[0].Job.Run()
+	}
+
+	server := httptest.NewServer(cronx.NewHandler(
+		manager,
+		cronx.WithPrefix("/admin/cron"),
+		cronx.WithAuth(cronx.NewTokenAuth(tokens)),
+	))
+	t.Cleanup(server.Close)
+	return server, manager
+}
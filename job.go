@@ -2,7 +2,10 @@ package cronx
 
 import (
 	"context"
+	"errors"
 	"reflect"
+	"runtime/debug"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -11,6 +14,8 @@ import (
 	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
 	"github.com/rizalgowandy/gdk/pkg/logx"
 	"github.com/rizalgowandy/gdk/pkg/netx"
+	"github.com/rizalgowandy/gdk/pkg/stack"
+	"github.com/rizalgowandy/gdk/pkg/tags"
 	"github.com/robfig/cron/v3"
 )
 
@@ -21,6 +26,14 @@ type JobItf interface {
 	Run(ctx context.Context) error
 }
 
+// JobWithResultItf is an optional interface for a job that reports the outcome of its run.
+// If implemented, RunWithResult is executed instead of Run,
+// and the result is stored together with the run history.
+type JobWithResultItf interface {
+	JobItf
+	RunWithResult(ctx context.Context) (storage.Result, error)
+}
+
 // NewJob creates a new job with default status and name.
 func NewJob(manager *Manager, job JobItf, waveNumber, totalWave int64) *Job {
 	return &Job{
@@ -33,6 +46,7 @@ func NewJob(manager *Manager, job JobItf, waveNumber, totalWave int64) *Job {
 		},
 		Name:    GetJobName(job),
 		Status:  StatusCodeUp,
+		Breaker: BreakerStateClosed,
 		Latency: "",
 		Error:   "",
 		inner:   job,
@@ -78,20 +92,89 @@ type Job struct {
 	Name    string     `json:"name"`
 	Status  StatusCode `json:"status"`
 	Latency string     `json:"latency"`
-	Error   string     `json:"error"`
-	PrevRun time.Time  `json:"prev_run"`
-	NextRun time.Time  `json:"next_run"`
+	// Schedule describes when the job runs.
+	Schedule string `json:"schedule"`
+	// StartedAt is when the last run has started.
+	StartedAt time.Time `json:"started_at"`
+	// Offset is the splay and jitter applied to the schedule of the last run, empty if none.
+	Offset string `json:"offset"`
+	// QueueWait is the time the last run has waited for a worker, empty if not waiting.
+	QueueWait string    `json:"queue_wait"`
+	Error     string    `json:"error"`
+	PrevRun   time.Time `json:"prev_run"`
+	NextRun   time.Time `json:"next_run"`
+	// Result is the outcome reported by the last successful run that has reported one.
+	Result storage.Result `json:"result"`
+	// ResultTrends is the change of each result counter compared to the result before.
+	ResultTrends map[string]int64 `json:"result_trends"`
+	// ConsecutiveFailures is the number of failed runs in a row.
+	ConsecutiveFailures int64 `json:"consecutive_failures"`
+	// Panics is the number of runs that have panicked.
+	Panics int64 `json:"panics"`
+	// SuccessWindow is how often the job is expected to succeed, empty if not expected.
+	SuccessWindow string `json:"success_window"`
+	// LastSuccess is when the last successful run has finished.
+	LastSuccess time.Time `json:"last_success"`
+	// Overdue determines if the job has not succeeded within its success window.
+	Overdue bool `json:"overdue"`
+	// Breaker is the state of the circuit breaker.
+	Breaker BreakerState `json:"breaker"`
+	// BreakerRetryAt is when the open circuit breaker lets the next run through.
+	BreakerRetryAt time.Time `json:"breaker_retry_at"`
 
 	manager *Manager
 	inner   JobItf
 	status  uint32
 	running sync.Mutex
 	latency int64
-	err     error
+	// schedule is the schedule of the job as given on registration.
+	schedule cron.Schedule
+	// offsets spreads the fire times of the job, nil if not configured.
+	offsets *OffsetSchedule
+	// offset is the time in nanoseconds the current run has been shifted from its schedule.
+	offset int64
+	// queueWait is the time in nanoseconds the current run has waited for a worker.
+	queueWait int64
+	err       error
+	// alerted determines if an alert has been sent for the current failures.
+	alerted bool
+	// overdueMu guards the overdue state, updated by both the run and the watchdog.
+	overdueMu sync.Mutex
+	// successWindow is how often the job is expected to succeed.
+	successWindow time.Duration
+	// since is when the job starts being expected to succeed.
+	since time.Time
+	// lastSuccess is the unix nano of the last successful run.
+	lastSuccess int64
+	// breakerMu guards the circuit breaker state.
+	breakerMu sync.Mutex
+	// canceller cancels the context of the current run.
+	canceller runCanceller
+	// cancelledBy is who has cancelled the current run, empty if not cancelled.
+	cancelledBy string
+	// runningSince is the unix nano when the current run has started, zero if not running.
+	runningSince int64
+}
+
+// Key returns the identifier of the job that stays the same across restarts.
+// Jobs with multiple waves have the wave number as suffix.
+func (j *Job) Key() string {
+	if j.TotalWave > 1 {
+		return j.Name + "#" + strconv.FormatInt(j.Wave, 10)
+	}
+	return j.Name
 }
 
 // UpdateStatus updates the current job status to the latest.
+// A change of the status is published to the subscribers of the manager.
 func (j *Job) UpdateStatus() StatusCode {
+	prev := j.Status
+	defer func() {
+		if j.Status != prev {
+			j.publish(EventStatusChanged, nil)
+		}
+	}()
+
 	switch atomic.LoadUint32(&j.status) {
 	case statusRunning:
 		j.Status = StatusCodeRunning
@@ -101,15 +184,114 @@ func (j *Job) UpdateStatus() StatusCode {
 		j.Status = StatusCodeDown
 	case statusError:
 		j.Status = StatusCodeError
+	case statusBroken:
+		j.Status = StatusCodeBroken
+	case statusQueued:
+		j.Status = StatusCodeQueued
+	case statusPanic:
+		j.Status = StatusCodePanic
+	case statusCompleted:
+		j.Status = StatusCodeCompleted
+	case statusCancelled:
+		j.Status = StatusCodeCancelled
+	case statusSkipped:
+		j.Status = StatusCodeSkipped
 	default:
 		j.Status = StatusCodeUp
 	}
 	return j.Status
 }
 
+// SetQueued marks the current run as waiting for a worker, or as running again once the worker is acquired.
+// It is meant for interceptors that queue the run, e.g. interceptor.PriorityWorkerPool.
+func (j *Job) SetQueued(queued bool) {
+	if queued {
+		atomic.CompareAndSwapUint32(&j.status, statusRunning, statusQueued)
+	} else {
+		atomic.CompareAndSwapUint32(&j.status, statusQueued, statusRunning)
+	}
+	j.UpdateStatus()
+}
+
+// RecordQueueWait records how long the current run has waited for a worker.
+// The wait is excluded from the run latency.
+func (j *Job) RecordQueueWait(wait time.Duration) {
+	atomic.AddInt64(&j.queueWait, wait.Nanoseconds())
+	if j.manager == nil {
+		return
+	}
+	j.manager.metrics.ObserveQueueWait(j.Name, wait)
+}
+
+// Metrics returns the collector of the manager running the job, nil if the job has no manager.
+// It lets an interceptor expose its own gauges, e.g. interceptor.WorkerPool.
+func (j *Job) Metrics() *Metrics {
+	if j.manager == nil {
+		return nil
+	}
+	return j.manager.metrics
+}
+
+// SetSuccessWindow sets how often the job is expected to succeed, starting from the given time.
+// Non-positive window means the job is never overdue.
+func (j *Job) SetSuccessWindow(window time.Duration, since time.Time) {
+	j.overdueMu.Lock()
+	defer j.overdueMu.Unlock()
+
+	j.successWindow = window
+	j.since = since
+	j.SuccessWindow = ""
+	if window > 0 {
+		j.SuccessWindow = window.String()
+	}
+}
+
+// UpdateOverdue updates the last success and whether the job has not succeeded within its success window.
+func (j *Job) UpdateOverdue(now time.Time) bool {
+	j.overdueMu.Lock()
+	defer j.overdueMu.Unlock()
+
+	since := j.since
+	if last := atomic.LoadInt64(&j.lastSuccess); last > 0 {
+		j.LastSuccess = time.Unix(0, last).In(j.manager.location)
+		since = j.LastSuccess
+	}
+
+	j.Overdue = j.successWindow > 0 && now.Sub(since) > j.successWindow
+	return j.Overdue
+}
+
+// lastSuccessTime returns when the last successful run has finished, zero if unknown.
+func (j *Job) lastSuccessTime() time.Time {
+	j.overdueMu.Lock()
+	defer j.overdueMu.Unlock()
+
+	return j.LastSuccess
+}
+
+// observeSuccess records the time of a successful run, unless a later one has been recorded.
+func (j *Job) observeSuccess(t time.Time) {
+	for {
+		last := atomic.LoadInt64(&j.lastSuccess)
+		if t.UnixNano() <= last {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&j.lastSuccess, last, t.UnixNano()) {
+			return
+		}
+	}
+}
+
 // Run executes the current job operation.
 func (j *Job) Run() {
 	start := time.Now()
+
+	// A fixed delay schedule also fires while the previous run is running, without running the job.
+	delay, isFixedDelay := j.schedule.(*FixedDelaySchedule)
+	if isFixedDelay && !delay.due(start) {
+		return
+	}
+
 	ctx := logx.NewContext()
 
 	prev := j.manager.GetEntry(j.EntryID).Prev
@@ -117,62 +299,421 @@ func (j *Job) Run() {
 	maxLatency := next.Sub(prev)
 
 	// Lock current process.
-	j.running.Lock()
+	// If the previous run is still running, current run cannot start on schedule.
+	if !j.running.TryLock() {
+		j.manager.notify(ctx, &Alert{
+			Event: AlertEventMissedSchedule,
+			Job:   j,
+			Fields: errorx.Fields{
+				"prev_schedule": prev.String(),
+				"next_schedule": next.String(),
+			},
+		})
+		j.running.Lock()
+	}
 	defer j.running.Unlock()
+	defer j.manager.afterRun(j)
+
+	// Skip the run while the circuit breaker is open.
+	if !j.allowRun(start) {
+		atomic.StoreUint32(&j.status, statusBroken)
+		j.UpdateStatus()
+		j.NextRun = next
+		j.PrevRun = prev
+		history := j.RecordBroken(ctx, start)
+		j.publish(EventRunFinished, history)
+		return
+	}
+
+	// Record how far the current run has been shifted from its schedule.
+	offset := j.scheduleOffset(prev)
+	atomic.StoreInt64(&j.offset, offset.Nanoseconds())
+	j.Offset = ""
+	if offset > 0 {
+		j.Offset = offset.String()
+	}
 
 	// Set job metadata.
 	ctx = SetJobMetadata(ctx, j.JobMetadata)
 
+	// Set log sink for the current run.
+	ctx = SetLogger(ctx, NewRunLogger(j.manager.logLimit))
+
+	// Let the operator cancel the current run.
+	ctx = j.canceller.start(ctx)
+	j.cancelledBy = ""
+
 	// Update job status as running.
+	j.StartedAt = start
+	atomic.StoreInt64(&j.runningSince, start.UnixNano())
 	atomic.StoreUint32(&j.status, statusRunning)
 	j.UpdateStatus()
 	j.NextRun = next
 	j.PrevRun = prev
+	j.manager.metrics.StartRun(j.Name)
+
+	// Record the start of the run, so a run that never finishes can still be traced.
+	history := j.RecordStart(ctx, start)
+	j.publish(EventRunStarted, history)
+	atomic.StoreInt64(&j.queueWait, 0)
 
 	// Run the job.
-	if err := j.manager.interceptor(ctx, j, func(ctx context.Context, job *Job) error {
-		return job.inner.Run(ctx)
-	}); err != nil {
-		j.err = err
-		j.Error = err.Error()
-		atomic.StoreUint32(&j.status, statusError)
+	result, runErr := j.run(ctx)
+	j.cancelledBy = j.canceller.finish()
+	atomic.StoreInt64(&j.runningSince, 0)
+	if j.cancelledBy != "" {
+		// Whatever the job returns, the operator has stopped the run.
+		runErr = NewCancelError(j, j.cancelledBy)
+		j.err = runErr
+		j.Error = runErr.Error()
+		atomic.StoreUint32(&j.status, statusCancelled)
+	} else if isSkipError(runErr) {
+		j.err = runErr
+		j.Error = runErr.Error()
+		atomic.StoreUint32(&j.status, statusSkipped)
+	} else if runErr != nil {
+		j.err = runErr
+		j.Error = runErr.Error()
+		if isPanicError(runErr) {
+			j.Panics++
+			atomic.StoreUint32(&j.status, statusPanic)
+		} else {
+			atomic.StoreUint32(&j.status, statusError)
+		}
 	} else {
+		j.err = nil
+		j.Error = ""
 		atomic.StoreUint32(&j.status, statusSuccess)
 	}
 
-	// Record time needed to execute the whole process.
+	// Record time needed to execute the whole process,
+	// the time spent waiting for a worker is recorded separately.
 	finish := time.Now()
-	latency := time.Since(start)
+	total := finish.Sub(start)
+	queueWait := time.Duration(atomic.LoadInt64(&j.queueWait))
+	latency := total - queueWait
 	j.latency = latency.Nanoseconds()
 	j.Latency = latency.String()
+	j.QueueWait = ""
+	if queueWait > 0 {
+		j.QueueWait = queueWait.String()
+	}
 
 	// Update job status after running.
 	j.UpdateStatus()
+	if runErr == nil {
+		j.observeSuccess(finish)
+		// A run without a result keeps the last result, so the trends still compare two results.
+		if len(result.Counters) > 0 || len(result.Values) > 0 {
+			j.UpdateResult(result)
+		}
+	}
+	j.UpdateOverdue(finish)
+	j.manager.metrics.FinishRun(j.Name, j.Status, latency, finish)
 
 	// Record history.
-	j.RecordHistory(ctx, start, finish)
+	history.Result = result
+	j.RecordHistory(ctx, history, finish)
+	j.publish(EventRunFinished, history)
+
+	// Send alert based on the run outcome.
+	// A cancelled run is neither a failure nor a success, the operator already knows about it.
+	// A skipped run has not run at all.
+	neutral := j.cancelledBy != "" || isSkipError(runErr)
+	if !neutral {
+		j.notifyOutcome(ctx, runErr, history)
+	}
+
+	// Open the circuit breaker if the job keeps failing.
+	if !neutral && j.updateBreaker(runErr, finish) {
+		j.manager.notify(ctx, &Alert{
+			Event:               AlertEventBroken,
+			Job:                 j,
+			History:             history,
+			Err:                 runErr,
+			ConsecutiveFailures: j.ConsecutiveFailures,
+			Fields:              errorx.Fields{"retry_at": j.BreakerRetryAt.String()},
+		})
+	}
+
+	// Send alert if the run has been starved of a worker.
+	threshold := j.manager.queueWaitThreshold
+	if threshold > 0 && queueWait > threshold {
+		j.manager.notify(ctx, &Alert{
+			Event:   AlertEventHighQueueWait,
+			Job:     j,
+			History: history,
+			Fields: errorx.Fields{
+				"queue_wait": queueWait.String(),
+				"threshold":  threshold.String(),
+			},
+		})
+	}
 
 	// Send alert if high latency is detected.
-	if latency > maxLatency && latency > time.Second {
-		j.manager.alerter.NotifyHighLatency(ctx, j, prev, next, latency, maxLatency)
+	// Schedules without a next run, e.g. once, or counting from the finish, e.g. fixed delay,
+	// can never be late for the next run.
+	if !next.IsZero() && !isFixedDelay && total > maxLatency && total > time.Second {
+		j.manager.notify(ctx, &Alert{
+			Event:   AlertEventHighLatency,
+			Job:     j,
+			History: history,
+			Fields: errorx.Fields{
+				"prev_schedule":   prev.String(),
+				"next_schedule":   next.String(),
+				"current_latency": total.String(),
+				"max_latency":     maxLatency.String(),
+			},
+		})
+	}
+}
+
+// run executes the job through the interceptors.
+// A panic is recovered as an error, regardless of the interceptors in use.
+func (j *Job) run(ctx context.Context) (result storage.Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = NewPanicError(j, r, debug.Stack())
+			logx.ERR(ctx, err, "recovered from panic")
+		}
+	}()
+
+	err = j.manager.interceptor(ctx, j, func(ctx context.Context, job *Job) error {
+		inner, ok := job.inner.(JobWithResultItf)
+		if !ok {
+			return job.inner.Run(ctx)
+		}
+
+		var err error
+		result, err = inner.RunWithResult(ctx)
+		return err
+	})
+	return result, err
+}
+
+// NewPanicError creates an error from a recovered panic and the stack trace of the panic.
+// The error marks the run as PANIC.
+// A panic error passed on by an interceptor, e.g. interceptor.Tracing, is returned as is,
+// so the stack trace of the original panic is kept.
+func NewPanicError(job *Job, r interface{}, stackTrace []byte) error {
+	if err, ok := r.(error); ok && isPanicError(err) {
+		return err
+	}
+
+	return errorx.E(
+		"there is a panic",
+		errorx.Op(job.Name),
+		errorx.CodeInternal,
+		errorx.Fields{
+			tags.StackTrace: stack.ToArr(stack.Trim(stackTrace)),
+			tags.Panic:      r,
+		},
+	)
+}
+
+// fieldSkipped is the error field marking a skipped run.
+const fieldSkipped = "skipped"
+
+// NewSkipError creates the error of a run skipped by an interceptor without calling the job,
+// e.g. interceptor.RateLimit when the bucket is exhausted.
+// The run is recorded as SKIPPED, and is neither alerted nor counted by the circuit breaker.
+func NewSkipError(job *Job, reason string, fields errorx.Fields) error {
+	skipFields := errorx.Fields{fieldSkipped: true}
+	for k, v := range fields {
+		skipFields[k] = v
+	}
+
+	return errorx.E(
+		reason,
+		errorx.Op(job.Name),
+		errorx.CodeConflict,
+		errorx.MetricStatusExpectedErr,
+		skipFields,
+	)
+}
+
+// isSkipError returns true if the error is created by NewSkipError.
+func isSkipError(err error) bool {
+	var e *errorx.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	_, ok := e.Fields[fieldSkipped]
+	return ok
+}
+
+// notifyOutcome sends alert for failed runs, and for the first successful run after failures.
+func (j *Job) notifyOutcome(ctx context.Context, err error, history *storage.History) {
+	if err == nil {
+		j.breakerMu.Lock()
+		failures := j.ConsecutiveFailures
+		j.ConsecutiveFailures = 0
+		j.breakerMu.Unlock()
+		alerted := j.alerted
+		j.alerted = false
+		if alerted {
+			j.manager.notify(ctx, &Alert{
+				Event:               AlertEventRecovery,
+				Job:                 j,
+				History:             history,
+				ConsecutiveFailures: failures,
+			})
+		}
+		return
+	}
+
+	j.breakerMu.Lock()
+	j.ConsecutiveFailures++
+	failures := j.ConsecutiveFailures
+	j.breakerMu.Unlock()
+
+	event := AlertEventFailure
+	switch {
+	case isPanicError(err):
+		event = AlertEventPanic
+	case isTimeoutError(err):
+		event = AlertEventTimeout
+	}
+
+	// No need to notify on expected error.
+	if event != AlertEventFailure || !isExpectedError(err) {
+		j.alerted = true
+		j.manager.notify(ctx, &Alert{
+			Event:               event,
+			Job:                 j,
+			History:             history,
+			Err:                 err,
+			ConsecutiveFailures: failures,
+		})
+	}
+
+	if failures == j.manager.alertThreshold {
+		j.alerted = true
+		j.manager.notify(ctx, &Alert{
+			Event:               AlertEventConsecutiveFailure,
+			Job:                 j,
+			History:             history,
+			Err:                 err,
+			ConsecutiveFailures: failures,
+		})
+	}
+}
+
+// isExpectedError returns true if the error is marked as an expected error.
+func isExpectedError(err error) bool {
+	var e *errorx.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	return e.MetricStatus == errorx.MetricStatusExpectedErr
+}
+
+// isTimeoutError returns true if the run has exceeded its deadline.
+// The error wrapped by errorx is unwrapped, since errorx.Error does not implement Unwrap.
+func isTimeoutError(err error) bool {
+	for err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return true
+		}
+
+		var e *errorx.Error
+		if !errors.As(err, &e) {
+			return false
+		}
+		err = e.Err
+	}
+	return false
+}
+
+// isPanicError returns true if the error is created from a recovered panic.
+func isPanicError(err error) bool {
+	var e *errorx.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	_, ok := e.Fields[tags.Panic]
+	return ok
+}
+
+// UpdateResult updates the last run result and the trend of its counters.
+func (j *Job) UpdateResult(result storage.Result) {
+	trends := make(map[string]int64, len(result.Counters))
+	for k, v := range result.Counters {
+		if prev, ok := j.Result.Counters[k]; ok {
+			trends[k] = v - prev
+		}
+	}
+
+	j.Result = result
+	j.ResultTrends = trends
+}
+
+// scheduleOffset returns the splay and jitter applied to the fire time.
+func (j *Job) scheduleOffset(at time.Time) time.Duration {
+	if j.offsets == nil {
+		return 0
+	}
+	return j.offsets.Offset(at)
+}
+
+// offsetText returns the offset applied to the fire time as text, empty if none.
+func (j *Job) offsetText(at time.Time) string {
+	offset := j.scheduleOffset(at)
+	if offset <= 0 {
+		return ""
 	}
+	return offset.String()
 }
 
-func (j *Job) RecordHistory(ctx context.Context, start, finish time.Time) {
+// RecordStart records the current run as running.
+// The returned history must be passed to RecordHistory once the run is finished.
+func (j *Job) RecordStart(ctx context.Context, start time.Time) *storage.History {
+	history := j.newHistory(start)
+	if err := j.manager.storage.WriteHistory(ctx, history); err != nil {
+		logx.ERR(ctx, errorx.E(err), "write running history must success")
+	}
+
+	return history
+}
+
+// RecordBroken records the current run as skipped by the open circuit breaker.
+func (j *Job) RecordBroken(ctx context.Context, start time.Time) *storage.History {
+	history := j.newHistory(start)
+	history.Status = StatusCodeBroken.String()
+	history.StatusCode = int64(statusBroken)
+	history.FinishedAt = start
+	history.Error = storage.NewErrorDetail(errorx.E(
+		"circuit breaker is open",
+		errorx.Op(j.Name),
+		errorx.CodeCircuitBreaker,
+		errorx.Fields{"retry_at": j.breakerRetryAt().String()},
+	))
+
+	if err := j.manager.storage.WriteHistory(ctx, history); err != nil {
+		logx.ERR(ctx, errorx.E(err), "write broken history must success")
+	}
+
+	return history
+}
+
+// newHistory creates the running history of the current run.
+func (j *Job) newHistory(start time.Time) *storage.History {
 	history := &storage.History{
 		ID:          0,
 		CreatedAt:   time.Now(),
 		Name:        j.Name,
-		Status:      j.Status.String(),
-		StatusCode:  int64(j.status),
+		Status:      StatusCodeRunning.String(),
+		StatusCode:  int64(statusRunning),
 		StartedAt:   start,
-		FinishedAt:  finish,
-		Latency:     j.latency,
-		LatencyText: j.Latency,
+		FinishedAt:  time.Time{},
+		Latency:     0,
+		LatencyText: "",
 		Error:       storage.ErrorDetail{},
 		Metadata: storage.HistoryMetadata{
-			MachineID: netx.GetIPv4(),
-			EntryID:   int64(j.JobMetadata.EntryID),
+			MachineID:  netx.GetIPv4(),
+			EntryID:    int64(j.JobMetadata.EntryID),
+			Offset:     atomic.LoadInt64(&j.offset),
+			OffsetText: j.Offset,
 		},
 	}
 
@@ -183,23 +724,31 @@ func (j *Job) RecordHistory(ctx context.Context, start, finish time.Time) {
 		history.Metadata.IsLastWave = j.JobMetadata.IsLastWave
 	}
 
-	// Add error detail.
-	if j.err != nil {
-		history.Error.Err = j.err.Error()
-		if e, ok := j.err.(*errorx.Error); ok {
-			history.Error = storage.ErrorDetail{
-				Err:          e.Err.Error(),
-				Code:         e.Code,
-				Fields:       e.Fields,
-				OpTraces:     e.OpTraces,
-				Message:      e.Message,
-				Line:         e.Line,
-				MetricStatus: e.MetricStatus,
-			}
+	return history
+}
+
+// RecordHistory records the outcome of the current run.
+func (j *Job) RecordHistory(ctx context.Context, history *storage.History, finish time.Time) {
+	history.Status = j.Status.String()
+	history.StatusCode = int64(j.status)
+	history.FinishedAt = finish
+	history.Latency = j.latency
+	history.LatencyText = j.Latency
+	history.QueueWait = atomic.LoadInt64(&j.queueWait)
+	history.QueueWaitText = j.QueueWait
+	history.Error = storage.NewErrorDetail(j.err)
+	history.Logs = Logger(ctx).Logs()
+	history.Metadata.CancelledBy = j.cancelledBy
+
+	// Fallback to a new history if the running history has failed to be recorded.
+	if history.ID == 0 {
+		if err := j.manager.storage.WriteHistory(ctx, history); err != nil {
+			logx.ERR(ctx, errorx.E(err), "write history must success")
 		}
+		return
 	}
 
-	if err := j.manager.storage.WriteHistory(ctx, history); err != nil {
-		logx.ERR(ctx, errorx.E(err), "write history must success")
+	if err := j.manager.storage.UpdateHistory(ctx, history); err != nil {
+		logx.ERR(ctx, errorx.E(err), "update history must success")
 	}
 }
@@ -4,10 +4,38 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/rizalgowandy/cronx/storage"
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/rizalgowandy/gdk/pkg/tags"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+type historyRecorder struct {
+	storageStub
+	written  []storage.History
+	updated  []storage.History
+	abandons []storage.AbandonFilter
+}
+
+func (h *historyRecorder) WriteHistory(_ context.Context, req *storage.History) error {
+	req.ID = int64(len(h.written) + 1)
+	h.written = append(h.written, *req)
+	return nil
+}
+
+func (h *historyRecorder) UpdateHistory(_ context.Context, req *storage.History) error {
+	h.updated = append(h.updated, *req)
+	return nil
+}
+
+func (h *historyRecorder) AbandonHistories(_ context.Context, req *storage.AbandonFilter) (int64, error) {
+	h.abandons = append(h.abandons, *req)
+	return 0, nil
+}
+
 func TestJob_Run(t *testing.T) {
 	type fields struct {
 		Name    string
@@ -53,6 +81,200 @@ func TestJob_Run(t *testing.T) {
 	}
 }
 
+func TestJob_RunRecordsHistoryInTwoPhases(t *testing.T) {
+	recorder := &historyRecorder{}
+	manager := NewManager(WithAutoStartDisabled(), WithStorage(recorder))
+	j := NewJob(manager, Func(func(ctx context.Context) error {
+		Logger(ctx).Info("started", nil)
+		return errors.New("error")
+	}), 1, 1)
+
+	j.Run()
+
+	require.Len(t, recorder.written, 1)
+	assert.Equal(t, StatusCodeRunning.String(), recorder.written[0].Status)
+	assert.True(t, recorder.written[0].FinishedAt.IsZero())
+
+	require.Len(t, recorder.updated, 1)
+	assert.Equal(t, recorder.written[0].ID, recorder.updated[0].ID)
+	assert.Equal(t, StatusCodeError.String(), recorder.updated[0].Status)
+	assert.Equal(t, "error", recorder.updated[0].Error.Err)
+	require.Len(t, recorder.updated[0].Logs.Lines, 1)
+	assert.Equal(t, "started", recorder.updated[0].Logs.Lines[0].Message)
+	assert.False(t, recorder.updated[0].FinishedAt.IsZero())
+}
+
+type invoiceJob struct {
+	processed int64
+}
+
+func (i *invoiceJob) Run(ctx context.Context) error {
+	_, err := i.RunWithResult(ctx)
+	return err
+}
+
+func (i *invoiceJob) RunWithResult(context.Context) (storage.Result, error) {
+	i.processed += 10
+	return storage.Result{
+		Counters: map[string]int64{"processed": i.processed},
+		Values:   map[string]string{"batch": "daily"},
+	}, nil
+}
+
+func TestJob_RunWithResult(t *testing.T) {
+	recorder := &historyRecorder{}
+	manager := NewManager(WithAutoStartDisabled(), WithStorage(recorder))
+	j := NewJob(manager, &invoiceJob{}, 1, 1)
+
+	j.Run()
+	assert.Equal(t, int64(10), j.Result.Counters["processed"])
+	assert.Empty(t, j.ResultTrends)
+
+	j.Run()
+	assert.Equal(t, int64(20), j.Result.Counters["processed"])
+	assert.Equal(t, map[string]int64{"processed": 10}, j.ResultTrends)
+
+	require.Len(t, recorder.updated, 2)
+	assert.Equal(t, j.Result, recorder.updated[1].Result)
+}
+
+type flakyInvoiceJob struct {
+	invoiceJob
+	err    error
+	silent bool
+}
+
+func (f *flakyInvoiceJob) RunWithResult(ctx context.Context) (storage.Result, error) {
+	if f.silent {
+		return storage.Result{}, f.err
+	}
+	result, _ := f.invoiceJob.RunWithResult(ctx)
+	return result, f.err
+}
+
+func TestJob_RunWithResultFailed(t *testing.T) {
+	recorder := &historyRecorder{}
+	manager := NewManager(WithAutoStartDisabled(), WithStorage(recorder))
+	inner := &flakyInvoiceJob{}
+	j := NewJob(manager, inner, 1, 1)
+
+	j.Run()
+	j.Run()
+	want := j.Result
+	assert.Equal(t, int64(20), want.Counters["processed"])
+	assert.Equal(t, map[string]int64{"processed": 10}, j.ResultTrends)
+
+	// A failed run keeps the last result and trends, its partial result is only kept on its history.
+	inner.err = errors.New("partner is down")
+	j.Run()
+	assert.Equal(t, want, j.Result)
+	assert.Equal(t, map[string]int64{"processed": 10}, j.ResultTrends)
+	require.Len(t, recorder.updated, 3)
+	assert.Equal(t, int64(30), recorder.updated[2].Result.Counters["processed"])
+
+	// A run without a result keeps them as well.
+	inner.err = nil
+	inner.silent = true
+	j.Run()
+	assert.Equal(t, want, j.Result)
+	assert.Equal(t, map[string]int64{"processed": 10}, j.ResultTrends)
+	assert.Empty(t, recorder.updated[3].Result)
+
+	// The next result is compared to the last one.
+	inner.silent = false
+	j.Run()
+	assert.Equal(t, int64(40), j.Result.Counters["processed"])
+	assert.Equal(t, map[string]int64{"processed": 20}, j.ResultTrends)
+}
+
+func TestJob_RunRecordsQueueWait(t *testing.T) {
+	recorder := &historyRecorder{}
+	alerter := &alertRecorder{}
+	manager := NewManager(
+		WithAutoStartDisabled(),
+		WithStorage(recorder),
+		WithAlerter(alerter),
+		WithQueueWaitThreshold(time.Minute),
+		WithInterceptor(func(ctx context.Context, job *Job, handler Handler) error {
+			job.RecordQueueWait(2 * time.Minute)
+			return handler(ctx, job)
+		}),
+	)
+	j := NewJob(manager, Func(func(ctx context.Context) error { return nil }), 1, 1)
+
+	j.Run()
+	assert.Equal(t, "2m0s", j.QueueWait)
+	require.Len(t, recorder.updated, 1)
+	assert.Equal(t, (2 * time.Minute).Nanoseconds(), recorder.updated[0].QueueWait)
+	assert.Equal(t, "2m0s", recorder.updated[0].QueueWaitText)
+	assert.Equal(t, []AlertEvent{AlertEventHighQueueWait}, alerter.events())
+}
+
+func TestJob_RunRecordsPanic(t *testing.T) {
+	recorder := &historyRecorder{}
+	alerter := &alertRecorder{}
+	manager := NewManager(WithAutoStartDisabled(), WithStorage(recorder), WithAlerter(alerter))
+	j := NewJob(manager, Func(func(ctx context.Context) error { panic("boom") }), 1, 1)
+
+	j.Run()
+	assert.Equal(t, StatusCodePanic, j.Status)
+	assert.Equal(t, int64(1), j.Panics)
+	assert.Equal(t, int64(1), j.ConsecutiveFailures)
+	assert.Equal(t, []AlertEvent{AlertEventPanic}, alerter.events())
+
+	require.Len(t, recorder.updated, 1)
+	assert.Equal(t, StatusCodePanic.String(), recorder.updated[0].Status)
+	assert.Equal(t, int64(statusPanic), recorder.updated[0].StatusCode)
+	assert.NotEmpty(t, recorder.updated[0].Error.Stack)
+	assert.Equal(t, "boom", recorder.updated[0].Error.Fields[tags.Panic])
+
+	j.inner = Func(func(ctx context.Context) error { return nil })
+	j.Run()
+	assert.Equal(t, StatusCodeSuccess, j.Status)
+	assert.Equal(t, int64(1), j.Panics)
+}
+
+func TestJob_RunRecordsSkip(t *testing.T) {
+	recorder := &historyRecorder{}
+	alerter := &alertRecorder{}
+	manager := NewManager(
+		WithAutoStartDisabled(),
+		WithStorage(recorder),
+		WithAlerter(alerter),
+		WithAlertFailureThreshold(1),
+		WithCircuitBreaker(1, time.Hour),
+	)
+	j := NewJob(manager, Func(func(ctx context.Context) error { return nil }), 1, 1)
+	j.inner = Func(func(context.Context) error {
+		return NewSkipError(j, "rate limit exceeded", errorx.Fields{"rate_limit_group": "partner"})
+	})
+
+	j.Run()
+	assert.Equal(t, StatusCodeSkipped, j.Status)
+	assert.Zero(t, j.ConsecutiveFailures)
+	assert.Equal(t, BreakerStateClosed, j.Breaker)
+	assert.Empty(t, alerter.events())
+
+	require.Len(t, recorder.updated, 1)
+	assert.Equal(t, StatusCodeSkipped.String(), recorder.updated[0].Status)
+	assert.Equal(t, int64(statusSkipped), recorder.updated[0].StatusCode)
+	assert.Equal(t, "partner", recorder.updated[0].Error.Fields["rate_limit_group"])
+}
+
+func TestNewManagerAbandonsRunningHistories(t *testing.T) {
+	recorder := &historyRecorder{}
+	NewManager(WithAutoStartDisabled(), WithStorage(recorder), WithAbandonThreshold(time.Minute))
+
+	require.Len(t, recorder.abandons, 1)
+	assert.Equal(t, StatusCodeRunning.String(), recorder.abandons[0].RunningStatus)
+	assert.Equal(t, StatusCodeAbandoned.String(), recorder.abandons[0].Status)
+	assert.WithinDuration(t, time.Now().Add(-time.Minute), recorder.abandons[0].StartedBefore, time.Second)
+
+	recorder = &historyRecorder{}
+	NewManager(WithAutoStartDisabled(), WithStorage(recorder), WithAbandonThreshold(0))
+	assert.Empty(t, recorder.abandons)
+}
+
 func TestJob_UpdateStatus(t *testing.T) {
 	type fields struct {
 		Name    string
@@ -101,6 +323,41 @@ func TestJob_UpdateStatus(t *testing.T) {
 			},
 			want: StatusCodeError,
 		},
+		{
+			name: "StatusCodeBroken",
+			fields: fields{
+				status: statusBroken,
+			},
+			want: StatusCodeBroken,
+		},
+		{
+			name: "StatusCodeQueued",
+			fields: fields{
+				status: statusQueued,
+			},
+			want: StatusCodeQueued,
+		},
+		{
+			name: "StatusCodePanic",
+			fields: fields{
+				status: statusPanic,
+			},
+			want: StatusCodePanic,
+		},
+		{
+			name: "StatusCodeCompleted",
+			fields: fields{
+				status: statusCompleted,
+			},
+			want: StatusCodeCompleted,
+		},
+		{
+			name: "StatusCodeSkipped",
+			fields: fields{
+				status: statusSkipped,
+			},
+			want: StatusCodeSkipped,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -118,6 +375,21 @@ func TestJob_UpdateStatus(t *testing.T) {
 	}
 }
 
+func TestJob_SetQueued(t *testing.T) {
+	t.Parallel()
+
+	j := &Job{status: statusRunning}
+	j.SetQueued(true)
+	assert.Equal(t, StatusCodeQueued, j.Status)
+	j.SetQueued(false)
+	assert.Equal(t, StatusCodeRunning, j.Status)
+
+	// Only a running job can be queued.
+	j = &Job{status: statusSuccess}
+	j.SetQueued(true)
+	assert.Equal(t, StatusCodeSuccess, j.Status)
+}
+
 func TestNewJob(t *testing.T) {
 	type args struct {
 		job        JobItf
@@ -0,0 +1,138 @@
+package cronx
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rizalgowandy/cronx/storage"
+	"github.com/rizalgowandy/gdk/pkg/jsonx"
+	"github.com/rizalgowandy/gdk/pkg/logx"
+)
+
+// Log level for the lines captured by RunLogger.
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// Logger returns the log sink of the current run.
+// Lines written outside a run are discarded.
+// Example:
+//
+//	cronx.Logger(ctx).Info("invoices processed", logx.KV{"total": 1234})
+func Logger(ctx context.Context) *RunLogger {
+	if ctx == nil {
+		return NewRunLogger(0)
+	}
+
+	logger, ok := ctx.Value(CtxKeyRunLogger).(*RunLogger)
+	if !ok {
+		return NewRunLogger(0)
+	}
+
+	return logger
+}
+
+// SetLogger stores the log sink of the current run inside current context.
+func SetLogger(ctx context.Context, logger *RunLogger) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return context.WithValue(ctx, CtxKeyRunLogger, logger)
+}
+
+// NewRunLogger creates a log sink that buffers lines up to limit bytes.
+// Lines beyond the limit are dropped and the logs are marked as truncated.
+// Non-positive limit discards every line.
+func NewRunLogger(limit int) *RunLogger {
+	return &RunLogger{
+		mu:        sync.Mutex{},
+		limit:     limit,
+		size:      0,
+		lines:     nil,
+		truncated: false,
+	}
+}
+
+// RunLogger is a log sink for a single run.
+// The captured lines are stored together with the run history.
+type RunLogger struct {
+	mu        sync.Mutex
+	limit     int
+	size      int
+	lines     []storage.LogLine
+	truncated bool
+}
+
+// Debug captures a line with debug level.
+func (l *RunLogger) Debug(msg string, fields logx.KV) {
+	l.write(LogLevelDebug, msg, fields)
+}
+
+// Info captures a line with info level.
+func (l *RunLogger) Info(msg string, fields logx.KV) {
+	l.write(LogLevelInfo, msg, fields)
+}
+
+// Warn captures a line with warn level.
+func (l *RunLogger) Warn(msg string, fields logx.KV) {
+	l.write(LogLevelWarn, msg, fields)
+}
+
+// Error captures a line with error level.
+func (l *RunLogger) Error(msg string, fields logx.KV) {
+	l.write(LogLevelError, msg, fields)
+}
+
+// Logs returns the captured lines.
+func (l *RunLogger) Logs() storage.HistoryLogs {
+	if l == nil {
+		return storage.HistoryLogs{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lines := make([]storage.LogLine, len(l.lines))
+	copy(lines, l.lines)
+
+	return storage.HistoryLogs{
+		Lines:     lines,
+		Truncated: l.truncated,
+	}
+}
+
+func (l *RunLogger) write(level, msg string, fields logx.KV) {
+	if l == nil || l.limit <= 0 {
+		return
+	}
+
+	size := len(level) + len(msg)
+	if len(fields) > 0 {
+		b, err := jsonx.Marshal(fields)
+		if err != nil {
+			fields = logx.KV{"error": err.Error()}
+		}
+		size += len(b)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.size+size > l.limit {
+		l.truncated = true
+		return
+	}
+
+	l.size += size
+	l.lines = append(l.lines, storage.LogLine{
+		Time:    time.Now(),
+		Level:   level,
+		Message: msg,
+		Fields:  fields,
+	})
+}
@@ -0,0 +1,53 @@
+package cronx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rizalgowandy/gdk/pkg/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+
+	logger := NewRunLogger(DefaultLogLimit)
+	ctx := SetLogger(context.Background(), logger)
+
+	Logger(ctx).Info("invoices processed", logx.KV{"total": 1234})
+	Logger(ctx).Error("invoice skipped", nil)
+
+	logs := logger.Logs()
+	require.Len(t, logs.Lines, 2)
+	assert.Equal(t, LogLevelInfo, logs.Lines[0].Level)
+	assert.Equal(t, "invoices processed", logs.Lines[0].Message)
+	assert.Equal(t, 1234, logs.Lines[0].Fields["total"])
+	assert.Equal(t, LogLevelError, logs.Lines[1].Level)
+	assert.False(t, logs.Truncated)
+}
+
+func TestLoggerWithoutRun(t *testing.T) {
+	t.Parallel()
+
+	for _, ctx := range []context.Context{nil, context.Background()} { //nolint:staticcheck
+		logger := Logger(ctx)
+		require.NotNil(t, logger)
+
+		logger.Info("discarded", nil)
+		assert.Empty(t, logger.Logs().Lines)
+	}
+}
+
+func TestRunLoggerTruncated(t *testing.T) {
+	t.Parallel()
+
+	logger := NewRunLogger(20)
+	logger.Debug("0123456789", nil)
+	logger.Warn("0123456789", nil)
+
+	logs := logger.Logs()
+	require.Len(t, logs.Lines, 1)
+	assert.Equal(t, LogLevelDebug, logs.Lines[0].Level)
+	assert.True(t, logs.Truncated)
+}
@@ -0,0 +1,374 @@
+package cronx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// List of metric names exposed by the manager.
+const (
+	MetricJobRuns        = "cronx_job_runs_total"
+	MetricJobLatency     = "cronx_job_latency_seconds"
+	MetricJobLastSuccess = "cronx_job_last_success_timestamp_seconds"
+	MetricJobNextRun     = "cronx_job_next_run_timestamp_seconds"
+	MetricJobRunning     = "cronx_job_running"
+	MetricJobsDown       = "cronx_jobs_down"
+	MetricJobQueueWait   = "cronx_job_queue_wait_seconds"
+)
+
+// DefaultLatencyBuckets defines the upper bounds in seconds of the latency and queue wait histograms.
+var DefaultLatencyBuckets = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800, 3600}
+
+// NewMetrics creates a collector for job run metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		mu:          sync.Mutex{},
+		buckets:     DefaultLatencyBuckets,
+		runs:        map[[2]string]float64{},
+		latencies:   map[string]*histogram{},
+		lastSuccess: map[string]float64{},
+		running:     map[string]float64{},
+		queueWaits:  map[string]*histogram{},
+		gauges:      nil,
+	}
+}
+
+// Metrics collects job run metrics and exposes them in the Prometheus text format.
+type Metrics struct {
+	mu          sync.Mutex
+	buckets     []float64
+	runs        map[[2]string]float64
+	latencies   map[string]*histogram
+	lastSuccess map[string]float64
+	running     map[string]float64
+	queueWaits  map[string]*histogram
+	gauges      []*gauge
+}
+
+// RegisterGauge registers a gauge whose value is read on every scrape.
+// Gauges with the same name must use the same help and the same label keys,
+// and a gauge with the same name and labels replaces the one registered before.
+// The returned function unregisters the gauge, so it is no longer exposed.
+func (m *Metrics) RegisterGauge(name, help string, labels map[string]string, value func() float64) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g := &gauge{
+		name:   name,
+		help:   help,
+		labels: labels,
+		value:  value,
+	}
+	m.gauges = slices.DeleteFunc(m.gauges, func(v *gauge) bool {
+		return v.name == name && maps.Equal(v.labels, labels)
+	})
+	m.gauges = append(m.gauges, g)
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		m.gauges = slices.DeleteFunc(m.gauges, func(v *gauge) bool {
+			return v == g
+		})
+	}
+}
+
+// StartRun records that a job has started running.
+func (m *Metrics) StartRun(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.running[name]++
+}
+
+// FinishRun records the outcome of a job run.
+func (m *Metrics) FinishRun(name string, status StatusCode, latency time.Duration, finish time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.running[name]--
+	m.runs[[2]string{name, status.String()}]++
+
+	m.observe(m.latencies, name, latency)
+
+	if status == StatusCodeSuccess {
+		m.lastSuccess[name] = float64(finish.UnixNano()) / 1e9
+	}
+}
+
+// ObserveQueueWait records how long a job run has waited for a worker.
+func (m *Metrics) ObserveQueueWait(name string, wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.observe(m.queueWaits, name, wait)
+}
+
+// observe adds the duration to the histogram of the job.
+func (m *Metrics) observe(histograms map[string]*histogram, name string, d time.Duration) {
+	h, ok := histograms[name]
+	if !ok {
+		h = &histogram{
+			counts: make([]float64, len(m.buckets)),
+			sum:    0,
+			count:  0,
+		}
+		histograms[name] = h
+	}
+	seconds := d.Seconds()
+	for k, v := range m.buckets {
+		if seconds <= v {
+			h.counts[k]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// Write writes all collected metrics in the Prometheus text format.
+func (m *Metrics) Write(w io.Writer) error {
+	// Read the gauges before taking the lock,
+	// since a gauge may wait for the lock of its owner, e.g. a worker pool recording a run.
+	gauges := m.gaugeFamilies()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	enc := newMetricEncoder(w)
+
+	runs := make([]metricSample, 0, len(m.runs))
+	for k, v := range m.runs {
+		runs = append(runs, metricSample{
+			name:   MetricJobRuns,
+			labels: [][2]string{{"name", k[0]}, {"status", k[1]}},
+			value:  v,
+		})
+	}
+	enc.family(MetricJobRuns, "Total number of job runs by status.", "counter", runs)
+
+	enc.family(
+		MetricJobLatency,
+		"Latency of job runs in seconds.",
+		"histogram",
+		m.histogramSamples(MetricJobLatency, m.latencies),
+	)
+	enc.family(
+		MetricJobQueueWait,
+		"Time job runs have waited for a worker in seconds.",
+		"histogram",
+		m.histogramSamples(MetricJobQueueWait, m.queueWaits),
+	)
+
+	enc.family(
+		MetricJobLastSuccess,
+		"Unix timestamp of the last successful job run.",
+		"gauge",
+		nameSamples(MetricJobLastSuccess, m.lastSuccess),
+	)
+	enc.family(
+		MetricJobRunning,
+		"Number of job runs currently running.",
+		"gauge",
+		nameSamples(MetricJobRunning, m.running),
+	)
+
+	for _, v := range gauges {
+		enc.family(v.name, v.help, "gauge", v.samples)
+	}
+
+	return enc.flush()
+}
+
+// gaugeFamilies reads the registered gauges grouped by name.
+// The values are read without holding the lock.
+func (m *Metrics) gaugeFamilies() []gaugeFamily {
+	m.mu.Lock()
+	gauges := make([]*gauge, len(m.gauges))
+	copy(gauges, m.gauges)
+	m.mu.Unlock()
+
+	sort.SliceStable(gauges, func(i, j int) bool {
+		return gauges[i].name < gauges[j].name
+	})
+
+	var families []gaugeFamily
+	for i := 0; i < len(gauges); {
+		j := i
+		samples := make([]metricSample, 0)
+		for ; j < len(gauges) && gauges[j].name == gauges[i].name; j++ {
+			samples = append(samples, metricSample{
+				name:   gauges[j].name,
+				labels: sortedLabels(gauges[j].labels),
+				value:  gauges[j].value(),
+			})
+		}
+		families = append(families, gaugeFamily{
+			name:    gauges[i].name,
+			help:    gauges[i].help,
+			samples: samples,
+		})
+		i = j
+	}
+	return families
+}
+
+func (m *Metrics) histogramSamples(metric string, histograms map[string]*histogram) []metricSample {
+	samples := make([]metricSample, 0, len(histograms)*(len(m.buckets)+3))
+	for _, name := range sortedKeys(histograms) {
+		h := histograms[name]
+		for k, v := range m.buckets {
+			samples = append(samples, metricSample{
+				name:   metric + "_bucket",
+				labels: [][2]string{{"name", name}, {"le", formatFloat(v)}},
+				value:  h.counts[k],
+			})
+		}
+		samples = append(samples,
+			metricSample{
+				name:   metric + "_bucket",
+				labels: [][2]string{{"name", name}, {"le", "+Inf"}},
+				value:  h.count,
+			},
+			metricSample{
+				name:   metric + "_sum",
+				labels: [][2]string{{"name", name}},
+				value:  h.sum,
+			},
+			metricSample{
+				name:   metric + "_count",
+				labels: [][2]string{{"name", name}},
+				value:  h.count,
+			},
+		)
+	}
+	return samples
+}
+
+type gauge struct {
+	name   string
+	help   string
+	labels map[string]string
+	value  func() float64
+}
+
+type gaugeFamily struct {
+	name    string
+	help    string
+	samples []metricSample
+}
+
+type histogram struct {
+	counts []float64
+	sum    float64
+	count  float64
+}
+
+type metricSample struct {
+	name   string
+	labels [][2]string
+	value  float64
+}
+
+func newMetricEncoder(w io.Writer) *metricEncoder {
+	return &metricEncoder{w: bufio.NewWriter(w)}
+}
+
+// metricEncoder writes metric families in the Prometheus text format.
+type metricEncoder struct {
+	w *bufio.Writer
+}
+
+func (e *metricEncoder) family(name, help, typ string, samples []metricSample) {
+	if len(samples) == 0 {
+		return
+	}
+
+	// Histogram samples are already ordered, sorting them would break the bucket order.
+	if typ != "histogram" {
+		sort.Slice(samples, func(i, j int) bool {
+			return labelString(samples[i].labels) < labelString(samples[j].labels)
+		})
+	}
+
+	_, _ = fmt.Fprintf(e.w, "# HELP %s %s\n", name, help)
+	_, _ = fmt.Fprintf(e.w, "# TYPE %s %s\n", name, typ)
+	for _, v := range samples {
+		_, _ = fmt.Fprintf(e.w, "%s%s %s\n", v.name, labelString(v.labels), formatFloat(v.value))
+	}
+}
+
+func (e *metricEncoder) flush() error {
+	return e.w.Flush()
+}
+
+func nameSamples(metric string, values map[string]float64) []metricSample {
+	samples := make([]metricSample, 0, len(values))
+	for k, v := range values {
+		samples = append(samples, metricSample{
+			name:   metric,
+			labels: [][2]string{{"name", k}},
+			value:  v,
+		})
+	}
+	return samples
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedLabels(labels map[string]string) [][2]string {
+	res := make([][2]string, 0, len(labels))
+	for _, k := range sortedKeys(labels) {
+		res = append(res, [2]string{k, labels[k]})
+	}
+	return res
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labelString(labels [][2]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("{")
+	for k, v := range labels {
+		if k > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(v[0])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(v[1]))
+		b.WriteString(`"`)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
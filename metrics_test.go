@@ -0,0 +1,127 @@
+package cronx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Write(t *testing.T) {
+	t.Parallel()
+
+	metrics := NewMetrics()
+	finish := time.Unix(1700000000, 0)
+
+	metrics.StartRun("payBill")
+	metrics.FinishRun("payBill", StatusCodeSuccess, 2*time.Second, finish)
+	metrics.StartRun("payBill")
+	metrics.FinishRun("payBill", StatusCodeError, 20*time.Second, finish)
+	metrics.StartRun("sendEmail")
+	metrics.ObserveQueueWait("payBill", 3*time.Second)
+	metrics.RegisterGauge("cronx_custom", "Custom gauge.", map[string]string{"key": `a"b`}, func() float64 {
+		return 7
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, metrics.Write(&buf))
+	out := buf.String()
+
+	assert.Contains(t, out, "# TYPE cronx_job_runs_total counter\n")
+	assert.Contains(t, out, `cronx_job_runs_total{name="payBill",status="ERROR"} 1`+"\n")
+	assert.Contains(t, out, `cronx_job_runs_total{name="payBill",status="SUCCESS"} 1`+"\n")
+	assert.Contains(t, out, `cronx_job_latency_seconds_bucket{name="payBill",le="1"} 0`+"\n")
+	assert.Contains(t, out, `cronx_job_latency_seconds_bucket{name="payBill",le="5"} 1`+"\n")
+	assert.Contains(t, out, `cronx_job_latency_seconds_bucket{name="payBill",le="30"} 2`+"\n")
+	assert.Contains(t, out, `cronx_job_latency_seconds_bucket{name="payBill",le="+Inf"} 2`+"\n")
+	assert.Contains(t, out, `cronx_job_latency_seconds_sum{name="payBill"} 22`+"\n")
+	assert.Contains(t, out, `cronx_job_latency_seconds_count{name="payBill"} 2`+"\n")
+	assert.Contains(t, out, `cronx_job_last_success_timestamp_seconds{name="payBill"} 1.7e+09`+"\n")
+	assert.Contains(t, out, `cronx_job_running{name="payBill"} 0`+"\n")
+	assert.Contains(t, out, `cronx_job_running{name="sendEmail"} 1`+"\n")
+	assert.Contains(t, out, `cronx_job_queue_wait_seconds_bucket{name="payBill",le="1"} 0`+"\n")
+	assert.Contains(t, out, `cronx_job_queue_wait_seconds_bucket{name="payBill",le="5"} 1`+"\n")
+	assert.Contains(t, out, `cronx_job_queue_wait_seconds_sum{name="payBill"} 3`+"\n")
+	assert.Contains(t, out, `cronx_custom{key="a\"b"} 7`+"\n")
+}
+
+func TestManager_WriteMetrics(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(WithAutoStartDisabled(), WithMetrics(NewMetrics()))
+	require.Error(t, m.Schedule("clearly a broken spec", Func(func(context.Context) error { return nil })))
+	require.NoError(t, m.ScheduleFunc("@every 5m", "payBill", func(context.Context) error {
+		return errors.New("error")
+	}))
+	m.Start()
+	defer m.Stop()
+	m.GetEntries()[0].Job.(*Job).Run()
+
+	var buf bytes.Buffer
+	require.NoError(t, m.WriteMetrics(&buf))
+	out := buf.String()
+
+	assert.Contains(t, out, `cronx_job_runs_total{name="payBill",status="ERROR"} 1`+"\n")
+	assert.Contains(t, out, `cronx_job_next_run_timestamp_seconds{entry_id="1",name="payBill"} `)
+	assert.Contains(t, out, "cronx_jobs_down 1\n")
+}
+
+func TestMetrics_WriteGaugeRecordingRun(t *testing.T) {
+	t.Parallel()
+
+	// A gauge may record on the same metrics while being read.
+	metrics := NewMetrics()
+	metrics.RegisterGauge("cronx_test_gauge", "Test gauge.", nil, func() float64 {
+		metrics.ObserveQueueWait("payBill", time.Second)
+		return 1
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, metrics.Write(&buf))
+	assert.Contains(t, buf.String(), "cronx_test_gauge 1\n")
+}
+
+func TestManager_Metrics(t *testing.T) {
+	t.Parallel()
+
+	// Every manager collects its own metrics.
+	first := NewManager(WithAutoStartDisabled())
+	second := NewManager(WithAutoStartDisabled())
+	assert.NotNil(t, first.Metrics())
+	assert.NotSame(t, first.Metrics(), second.Metrics())
+
+	metrics := NewMetrics()
+	assert.Same(t, metrics, NewManager(WithAutoStartDisabled(), WithMetrics(metrics)).Metrics())
+}
+
+func TestMetrics_RegisterGauge(t *testing.T) {
+	t.Parallel()
+
+	metrics := NewMetrics()
+	labels := map[string]string{"pool": "billing"}
+	unregisterOld := metrics.RegisterGauge("cronx_custom", "Custom gauge.", labels, func() float64 { return 1 })
+	unregister := metrics.RegisterGauge("cronx_custom", "Custom gauge.", labels, func() float64 { return 2 })
+	metrics.RegisterGauge("cronx_custom", "Custom gauge.", map[string]string{"pool": "email"}, func() float64 { return 3 })
+
+	var buf bytes.Buffer
+	require.NoError(t, metrics.Write(&buf))
+	assert.NotContains(t, buf.String(), `cronx_custom{pool="billing"} 1`+"\n")
+	assert.Contains(t, buf.String(), `cronx_custom{pool="billing"} 2`+"\n")
+	assert.Contains(t, buf.String(), `cronx_custom{pool="email"} 3`+"\n")
+
+	// The replaced gauge cannot unregister the one replacing it.
+	unregisterOld()
+	buf.Reset()
+	require.NoError(t, metrics.Write(&buf))
+	assert.Contains(t, buf.String(), `cronx_custom{pool="billing"} 2`+"\n")
+
+	unregister()
+	buf.Reset()
+	require.NoError(t, metrics.Write(&buf))
+	assert.NotContains(t, buf.String(), `pool="billing"`)
+	assert.Contains(t, buf.String(), `cronx_custom{pool="email"} 3`+"\n")
+}
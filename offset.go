@@ -0,0 +1,93 @@
+package cronx
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// NewOffsetSchedule creates a schedule that shifts every fire time of the schedule
+// by a deterministic splay derived from the key, plus a random jitter.
+// Both offsets should be smaller than the interval of the schedule,
+// otherwise some of the fire times are skipped.
+func NewOffsetSchedule(schedule cron.Schedule, key string, splay, jitter time.Duration) *OffsetSchedule {
+	return &OffsetSchedule{
+		schedule: schedule,
+		splay:    splayOffset(key, splay),
+		jitter:   jitter,
+		mu:       sync.Mutex{},
+		fires:    [2]offsetFire{},
+	}
+}
+
+// OffsetSchedule spreads the fire times of a schedule,
+// so the same job on many machines does not run at the same second.
+type OffsetSchedule struct {
+	schedule cron.Schedule
+	// splay is the fixed offset of every fire time.
+	splay time.Duration
+	// jitter is the maximum random offset added on top of the splay.
+	jitter time.Duration
+
+	mu sync.Mutex
+	// fires holds the offsets of the last two fire times,
+	// the one currently running and the next one.
+	fires [2]offsetFire
+}
+
+type offsetFire struct {
+	at     time.Time
+	offset time.Duration
+}
+
+// Next returns the next fire time of the schedule after the given time, including the offset.
+// The offset of the last fire time is removed first,
+// so schedules relative to the given time, e.g. @every, do not add up the offsets.
+func (s *OffsetSchedule) Next(t time.Time) time.Time {
+	offset := s.splay
+	if s.jitter > 0 {
+		offset += time.Duration(rand.Int64N(int64(s.jitter)))
+	}
+	next := s.schedule.Next(t.Add(-s.Offset(t)))
+	if next.IsZero() {
+		return next
+	}
+	next = next.Add(offset)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fires[0] = s.fires[1]
+	s.fires[1] = offsetFire{at: next, offset: offset}
+	return next
+}
+
+// Offset returns the offset applied to the last fire time at or before the given time,
+// or zero if there is none.
+// The scheduler asks for the next fire time a little after the fire time,
+// so the given time does not have to be the exact fire time.
+func (s *OffsetSchedule) Offset(at time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.fires) - 1; i >= 0; i-- {
+		v := s.fires[i]
+		if !v.at.IsZero() && !v.at.After(at) {
+			return v.offset
+		}
+	}
+	return 0
+}
+
+// splayOffset returns a stable offset in [0, splay) for the key on this machine.
+func splayOffset(key string, splay time.Duration) time.Duration {
+	if splay <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return time.Duration(h.Sum64() % uint64(splay))
+}
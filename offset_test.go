@@ -0,0 +1,157 @@
+package cronx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffsetSchedule(t *testing.T) {
+	t.Parallel()
+
+	every, err := cron.ParseStandard("@every 1m")
+	require.NoError(t, err)
+	minutely, err := cron.ParseStandard("* * * * *")
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule cron.Schedule
+		splay    time.Duration
+		jitter   time.Duration
+	}{
+		{
+			name:     "Splay",
+			schedule: minutely,
+			splay:    30 * time.Second,
+		},
+		{
+			name:     "Jitter",
+			schedule: minutely,
+			jitter:   30 * time.Second,
+		},
+		{
+			name:     "Splay and jitter",
+			schedule: minutely,
+			splay:    10 * time.Second,
+			jitter:   10 * time.Second,
+		},
+		{
+			name:     "Splay on @every",
+			schedule: every,
+			splay:    30 * time.Second,
+		},
+		{
+			name:     "Splay and jitter on @every",
+			schedule: every,
+			splay:    10 * time.Second,
+			jitter:   10 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := NewOffsetSchedule(tt.schedule, "payBill@10.0.0.1", tt.splay, tt.jitter)
+
+			next := s.Next(now)
+			offset := s.Offset(next)
+			assert.Equal(t, tt.schedule.Next(now).Add(offset), next)
+			assert.GreaterOrEqual(t, offset, time.Duration(0))
+			assert.Less(t, offset, tt.splay+tt.jitter)
+
+			// The offset of the running fire time is kept while the next one is scheduled.
+			after := s.Next(next)
+			assert.True(t, after.After(next))
+			assert.Equal(t, offset, s.Offset(next))
+			assert.Equal(t, time.Duration(0), s.Offset(now))
+
+			// The offsets do not add up from one fire time to the next.
+			for range 10 {
+				want := tt.schedule.Next(next.Add(-s.Offset(next)))
+				next, after = after, s.Next(after)
+				assert.Equal(t, want, next.Add(-s.Offset(next)))
+			}
+			assert.Equal(t, now.Add(12*time.Minute), after.Add(-s.Offset(after)))
+		})
+	}
+}
+
+func TestOffsetScheduleLateTimer(t *testing.T) {
+	t.Parallel()
+
+	every, err := cron.ParseStandard("@every 1m")
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		splay  time.Duration
+		jitter time.Duration
+	}{
+		{
+			name:  "Splay",
+			splay: 30 * time.Second,
+		},
+		{
+			name:   "Splay and jitter",
+			splay:  10 * time.Second,
+			jitter: 10 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := NewOffsetSchedule(every, "payBill@10.0.0.1", tt.splay, tt.jitter)
+
+			// The scheduler asks for the next fire time once its timer fires, a little after the fire time.
+			next := s.Next(now)
+			for i := range 10 {
+				next = s.Next(next.Add(3 * time.Millisecond))
+				assert.Equal(t, now.Add(time.Duration(i+2)*time.Minute), next.Add(-s.Offset(next)))
+			}
+		})
+	}
+}
+
+func TestOffsetScheduleSplayIsStable(t *testing.T) {
+	t.Parallel()
+
+	every := cron.Every(time.Minute)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a := NewOffsetSchedule(every, "payBill@10.0.0.1", time.Minute, 0)
+	b := NewOffsetSchedule(every, "payBill@10.0.0.1", time.Minute, 0)
+	c := NewOffsetSchedule(every, "payBill@10.0.0.2", time.Minute, 0)
+
+	assert.Equal(t, a.Next(now), b.Next(now))
+	assert.NotEqual(t, a.Next(now), c.Next(now))
+}
+
+func TestManagerScheduleWithOffset(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(
+		WithAutoStartDisabled(),
+		WithSplay("payBill", 30*time.Minute),
+		WithJitter("payBill", time.Minute),
+	)
+	require.NoError(t, m.ScheduleFunc("@every 1h", "payBill", func(context.Context) error { return nil }))
+	require.NoError(t, m.ScheduleFunc("@every 1h", "sendEmail", func(context.Context) error { return nil }))
+
+	m.Start()
+	defer m.Stop()
+
+	data := m.GetStatusData("")
+	require.Len(t, data.Data, 2)
+	assert.NotNil(t, data.Data[0].Job.offsets)
+	assert.NotEmpty(t, data.Data[0].NextOffset)
+	assert.Nil(t, data.Data[1].Job.offsets)
+	assert.Empty(t, data.Data[1].NextOffset)
+}
@@ -0,0 +1,32 @@
+package cronx
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rizalgowandy/gdk/pkg/jsonx"
+)
+
+// openAPI is the OpenAPI 3 document of every route of the server, see NewHandler.
+//
+//go:embed openapi.json
+var openAPI []byte
+
+// OpenAPI returns the OpenAPI document of the server, with the prefix of the routes as the server url.
+func (c *ServerController) OpenAPI(ctx echo.Context) error {
+	var doc map[string]interface{}
+	if err := jsonx.Unmarshal(openAPI, &doc); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	url := c.Prefix
+	if url == "" {
+		url = "/"
+	}
+	doc["servers"] = []map[string]string{{"url": url}}
+
+	return ctx.JSON(http.StatusOK, doc)
+}
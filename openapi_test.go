@@ -0,0 +1,313 @@
+package cronx
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rizalgowandy/cronx/storage"
+	"github.com/rizalgowandy/gdk/pkg/logx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openAPIDoc returns the embedded OpenAPI document.
+func openAPIDoc(t *testing.T) map[string]any {
+	t.Helper()
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(openAPI, &doc))
+	return doc
+}
+
+// responseSchema returns the schema of the response of the operation.
+func responseSchema(t *testing.T, doc map[string]any, path, method string, code int) map[string]any {
+	t.Helper()
+
+	op, ok := lookup(doc, "paths", path, method).(map[string]any)
+	require.True(t, ok, "%s %s is not documented", method, path)
+	res, ok := lookup(op, "responses", strconv.Itoa(code), "content").(map[string]any)
+	require.True(t, ok, "%s %s %d is not documented", method, path, code)
+	for _, v := range res {
+		return v.(map[string]any)["schema"].(map[string]any)
+	}
+	t.Fatalf("%s %s %d has no content", method, path, code)
+	return nil
+}
+
+// lookup returns the value of the keys in the nested maps, nil if not found.
+func lookup(v any, keys ...string) any {
+	for _, k := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[k]
+	}
+	return v
+}
+
+// validate returns every mismatch between the value and the schema,
+// i.e. a missing required property, a property not documented, or a wrong type.
+func validate(doc, schema map[string]any, v any, path string) []string {
+	if r, ok := schema["$ref"].(string); ok {
+		ref := lookup(doc, strings.Split(strings.TrimPrefix(r, "#/"), "/")...)
+		refSchema, ok := ref.(map[string]any)
+		if !ok {
+			return []string{path + ": unknown " + r}
+		}
+		return validate(doc, refSchema, v, path)
+	}
+	if v == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable || schema["type"] == nil {
+			return nil
+		}
+		return []string{path + ": null is not nullable"}
+	}
+
+	var errs []string
+	switch schema["type"] {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %T is not an object", path, v)}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, k := range required {
+			if _, ok := obj[k.(string)]; !ok {
+				errs = append(errs, path+"."+k.(string)+": required")
+			}
+		}
+		for k, val := range obj {
+			if prop, ok := props[k].(map[string]any); ok {
+				errs = append(errs, validate(doc, prop, val, path+"."+k)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case map[string]any:
+				errs = append(errs, validate(doc, additional, val, path+"."+k)...)
+			case bool:
+				if !additional {
+					errs = append(errs, path+"."+k+": not documented")
+				}
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %T is not an array", path, v)}
+		}
+		items, _ := schema["items"].(map[string]any)
+		for k, val := range arr {
+			errs = append(errs, validate(doc, items, val, path+"["+strconv.Itoa(k)+"]")...)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %T is not a string", path, v)}
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				errs = append(errs, path+": "+err.Error())
+			}
+		}
+		if enum, ok := schema["enum"].([]any); ok && !containsAny(enum, str) {
+			errs = append(errs, path+": "+str+" is not in the enum")
+		}
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			return []string{fmt.Sprintf("%s: %v is not an integer", path, v)}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return []string{fmt.Sprintf("%s: %T is not a boolean", path, v)}
+		}
+	}
+	return errs
+}
+
+func containsAny(arr []any, v any) bool {
+	for _, x := range arr {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// richHistories returns a history filling every documented field.
+type richHistories struct {
+	storageStub
+}
+
+func (richHistories) ReadHistories(context.Context, *storage.HistoryFilter) ([]storage.History, error) {
+	now := time.Now()
+	return []storage.History{
+		{
+			ID:          2,
+			CreatedAt:   now,
+			Name:        "payBill",
+			Status:      StatusCodePanic.String(),
+			StatusCode:  int64(statusPanic),
+			StartedAt:   now,
+			FinishedAt:  now.Add(time.Second),
+			Latency:     int64(time.Second),
+			LatencyText: time.Second.String(),
+			Error: storage.NewErrorDetail(NewPanicError(
+				&Job{Name: "payBill"}, "nil map", []byte("goroutine 1 [running]:\nmain.main()"),
+			)),
+			Metadata: storage.HistoryMetadata{
+				MachineID:   "10.0.0.1",
+				EntryID:     1,
+				Wave:        1,
+				TotalWave:   2,
+				IsLastWave:  false,
+				Offset:      int64(time.Second),
+				OffsetText:  "1s",
+				CancelledBy: "admin",
+			},
+			Logs: storage.HistoryLogs{
+				Lines: []storage.LogLine{
+					{Time: now, Level: "info", Message: "bills paid", Fields: map[string]interface{}{"count": 1}},
+				},
+				Truncated: true,
+			},
+			Result:        storage.Result{Counters: map[string]int64{"paid": 1}, Values: map[string]string{"batch": "a"}},
+			QueueWait:     int64(time.Second),
+			QueueWaitText: "1s",
+		},
+	}, nil
+}
+
+func TestOpenAPI_Routes(t *testing.T) {
+	doc := openAPIDoc(t)
+	e, _ := newEcho(NewManager(WithAutoStartDisabled()))
+
+	params := regexp.MustCompile(`:(\w+)`)
+	for _, route := range e.Routes() {
+		if route.Method == echo.RouteNotFound {
+			continue
+		}
+		path := params.ReplaceAllString(route.Path, "{$1}")
+		switch {
+		case path == "":
+			path = "/"
+		case strings.HasPrefix(path, "/static/"):
+			path = "/static/{version}/{path}"
+		}
+		assert.NotNil(t, lookup(doc, "paths", path, strings.ToLower(route.Method)),
+			"%s %s is not documented", route.Method, route.Path)
+	}
+}
+
+func TestOpenAPI_Responses(t *testing.T) {
+	doc := openAPIDoc(t)
+
+	// A job that has failed filling every documented field, and a job that is down.
+	manager := NewManager(WithAutoStartDisabled(), WithStorage(richHistories{}), WithCircuitBreaker(1, time.Hour))
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", func(ctx context.Context) error {
+		Logger(ctx).Info("bills paid", logx.KV{"count": 1})
+		return errors.New("partner is down")
+	}))
+	require.Error(t, manager.ScheduleFunc("invalid", "sendInvoice", func(context.Context) error { return nil }))
+	job := manager.GetEntries()[0].Job.(*Job)
+	job.SetSuccessWindow(time.Hour, time.Now())
+	job.Run()
+	id := strconv.Itoa(int(job.EntryID))
+
+	// A stopped manager failing the health checks.
+	manager.Start()
+	manager.Stop()
+
+	handler := NewHandler(manager, WithPrefix("/admin/cron"))
+	tests := []struct {
+		method string
+		path   string
+		target string
+		code   int
+	}{
+		{method: http.MethodGet, path: "/", target: "/", code: http.StatusOK},
+		{method: http.MethodGet, path: "/api/jobs", target: "/api/jobs?sort=name:asc", code: http.StatusOK},
+		{method: http.MethodGet, path: "/api/histories", target: "/api/histories?limit=1", code: http.StatusOK},
+		{method: http.MethodPost, path: "/api/jobs/{id}/cancel", target: "/api/jobs/" + id + "/cancel", code: http.StatusConflict},
+		{method: http.MethodPost, path: "/api/jobs/{id}/cancel", target: "/api/jobs/100/cancel", code: http.StatusNotFound},
+		{method: http.MethodPost, path: "/api/jobs/{id}/breaker/reset", target: "/api/jobs/" + id + "/breaker/reset", code: http.StatusOK},
+		{method: http.MethodPost, path: "/api/jobs/{id}/breaker/reset", target: "/api/jobs/abc/breaker/reset", code: http.StatusBadRequest},
+		{method: http.MethodGet, path: "/api/openapi.json", target: "/api/openapi.json", code: http.StatusOK},
+		{method: http.MethodGet, path: "/healthz", target: "/healthz", code: http.StatusServiceUnavailable},
+		{method: http.MethodGet, path: "/readyz", target: "/readyz", code: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/admin/cron"+tt.target, nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, tt.code, rec.Code, rec.Body.String())
+
+			var body any
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			schema := responseSchema(t, doc, tt.path, strings.ToLower(tt.method), tt.code)
+			assert.Empty(t, validate(doc, schema, body, "$"))
+		})
+	}
+}
+
+func TestOpenAPI_Events(t *testing.T) {
+	doc := openAPIDoc(t)
+	manager := NewManager(WithAutoStartDisabled())
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", func(context.Context) error { return nil }))
+
+	server := httptest.NewServer(NewHandler(manager))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/events", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	manager.GetEntries()[0].Job.Run()
+
+	schema := responseSchema(t, doc, "/api/events", "get", http.StatusOK)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var body any
+		require.NoError(t, json.Unmarshal([]byte(data), &body))
+		assert.Empty(t, validate(doc, schema, body, "$"))
+		return
+	}
+	t.Fatal("no event received")
+}
+
+func TestServerController_OpenAPI(t *testing.T) {
+	handler := NewHandler(NewManager(WithAutoStartDisabled()), WithPrefix("/admin/cron"))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/cron/api/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc["openapi"])
+	assert.Equal(t, "/admin/cron", lookup(doc, "servers").([]any)[0].(map[string]any)["url"])
+}
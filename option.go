@@ -52,9 +52,114 @@ func WithStorage(client storage.Client) Option {
 	}
 }
 
-// WithAlerter determines the alerter used to send notification for high latency job run detected.
+// WithAlerter determines the alerter used to send notification on unwanted job events.
 func WithAlerter(client AlerterItf) Option {
 	return func(m *Manager) {
 		m.alerter = client
 	}
 }
+
+// WithLogLimit determines the maximum size in bytes of the logs captured per run using Logger.
+// Non-positive value disables the capture.
+func WithLogLimit(limit int) Option {
+	return func(m *Manager) {
+		m.logLimit = limit
+	}
+}
+
+// WithAbandonThreshold determines how old a running history from this machine must be
+// to be marked as abandoned on startup.
+// Keep it above the longest run, so the runs of another manager on the same machine are left untouched.
+// Non-positive value disables the marking.
+func WithAbandonThreshold(threshold time.Duration) Option {
+	return func(m *Manager) {
+		m.abandonThreshold = threshold
+	}
+}
+
+// WithMetrics determines the collector used to record job run metrics.
+// Default is a collector of its own for every manager.
+func WithMetrics(metrics *Metrics) Option {
+	return func(m *Manager) {
+		m.metrics = metrics
+	}
+}
+
+// WithAlertCooldown determines how long the same alert of a job is suppressed after being sent.
+// Non-positive value sends every alert.
+func WithAlertCooldown(cooldown time.Duration) Option {
+	return func(m *Manager) {
+		m.alertCooldown = cooldown
+	}
+}
+
+// WithAlertFailureThreshold determines the number of failed runs in a row
+// that sends a consecutive failure alert.
+// Non-positive value disables the alert.
+func WithAlertFailureThreshold(threshold int64) Option {
+	return func(m *Manager) {
+		m.alertThreshold = threshold
+	}
+}
+
+// WithSuccessWindow determines that the job with the given name is expected to succeed at least once per window.
+// An overdue alert is sent when the job has not succeeded within the window.
+// Jobs with multiple waves are checked per wave.
+func WithSuccessWindow(name string, window time.Duration) Option {
+	return func(m *Manager) {
+		m.successWindows[name] = window
+	}
+}
+
+// WithWatchdogInterval determines how often the jobs with a success window are checked.
+// Non-positive value disables the check.
+func WithWatchdogInterval(interval time.Duration) Option {
+	return func(m *Manager) {
+		m.watchdogInterval = interval
+	}
+}
+
+// WithCircuitBreaker pauses a job after failing a number of times in a row.
+// The runs are skipped with BROKEN status until the cooldown has passed,
+// then a single run is tried to close the breaker on success or open it again on failure.
+// Non-positive threshold disables the circuit breaker.
+func WithCircuitBreaker(threshold int64, cooldown time.Duration) Option {
+	return func(m *Manager) {
+		m.breakerThreshold = threshold
+		m.breakerCooldown = cooldown
+	}
+}
+
+// WithSplay spreads the fire times of the job with the given name by a stable offset in [0, splay).
+// The offset is derived from the job and the machine,
+// so the same job on many machines runs at different seconds.
+// Splay should be smaller than the interval of the schedule.
+func WithSplay(name string, splay time.Duration) Option {
+	return func(m *Manager) {
+		m.splays[name] = splay
+	}
+}
+
+// WithJitter delays every fire time of the job with the given name by a random offset in [0, jitter).
+// Jitter is added on top of the splay, and should be smaller than the interval of the schedule.
+func WithJitter(name string, jitter time.Duration) Option {
+	return func(m *Manager) {
+		m.jitters[name] = jitter
+	}
+}
+
+// WithQueueWaitThreshold determines how long a run can wait for a worker before an alert is sent.
+// Non-positive value disables the alert.
+func WithQueueWaitThreshold(threshold time.Duration) Option {
+	return func(m *Manager) {
+		m.queueWaitThreshold = threshold
+	}
+}
+
+// WithHealthRules determines what counts as unhealthy on the liveness and readiness checks.
+// Start from DefaultHealthRules to only change some of the rules.
+func WithHealthRules(rules HealthRules) Option {
+	return func(m *Manager) {
+		m.healthRules = rules
+	}
+}
@@ -26,13 +26,21 @@ func (s storageStub) WriteHistory(context.Context, *storage.History) error {
 	return nil
 }
 
+func (s storageStub) UpdateHistory(context.Context, *storage.History) error {
+	return nil
+}
+
+func (s storageStub) AbandonHistories(context.Context, *storage.AbandonFilter) (int64, error) {
+	return 0, nil
+}
+
 func (s storageStub) ReadHistories(context.Context, *storage.HistoryFilter) ([]storage.History, error) {
 	return nil, nil
 }
 
 type alerterStub struct{}
 
-func (a *alerterStub) NotifyHighLatency(context.Context, *Job, time.Time, time.Time, time.Duration, time.Duration) {
+func (a *alerterStub) Notify(context.Context, *Alert) {
 }
 
 func TestWithParserOverride(t *testing.T) {
@@ -77,6 +85,7 @@ func TestManagerOptionsAssignment(t *testing.T) {
 	loc := time.FixedZone("WIB", 7*60*60)
 	storageClient := storageStub{}
 	alerterClient := &alerterStub{}
+	metrics := NewMetrics()
 
 	m := NewManager(
 		WithAutoStartDisabled(),
@@ -84,6 +93,16 @@ func TestManagerOptionsAssignment(t *testing.T) {
 		WithLowPriorityDownJobs(),
 		WithStorage(storageClient),
 		WithAlerter(alerterClient),
+		WithAbandonThreshold(time.Minute),
+		WithLogLimit(1024),
+		WithMetrics(metrics),
+		WithSuccessWindow("payBill", time.Hour),
+		WithWatchdogInterval(time.Second),
+		WithCircuitBreaker(5, time.Minute),
+		WithQueueWaitThreshold(time.Minute),
+		WithSplay("payBill", time.Minute),
+		WithJitter("payBill", time.Second),
+		WithHealthRules(HealthRules{MaxDownJobs: 2}),
 	)
 
 	assert.Equal(t, loc, m.location)
@@ -92,4 +111,15 @@ func TestManagerOptionsAssignment(t *testing.T) {
 	assert.False(t, m.highPriorityDownJobs)
 	assert.Same(t, alerterClient, m.alerter)
 	assert.Equal(t, storageClient, m.storage)
+	assert.Equal(t, time.Minute, m.abandonThreshold)
+	assert.Equal(t, 1024, m.logLimit)
+	assert.Same(t, metrics, m.metrics)
+	assert.Equal(t, map[string]time.Duration{"payBill": time.Hour}, m.successWindows)
+	assert.Equal(t, time.Second, m.watchdogInterval)
+	assert.Equal(t, int64(5), m.breakerThreshold)
+	assert.Equal(t, time.Minute, m.breakerCooldown)
+	assert.Equal(t, time.Minute, m.queueWaitThreshold)
+	assert.Equal(t, map[string]time.Duration{"payBill": time.Minute}, m.splays)
+	assert.Equal(t, map[string]time.Duration{"payBill": time.Second}, m.jitters)
+	assert.Equal(t, HealthRules{MaxDownJobs: 2}, m.healthRules)
 }
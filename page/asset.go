@@ -0,0 +1,51 @@
+package page
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+)
+
+//go:embed static
+var static embed.FS
+
+var (
+	// AssetVersion changes whenever any of the embedded assets changes,
+	// so the assets can be cached forever by the browser.
+	AssetVersion = assetVersion()
+	// StaticPath is the path where the embedded assets are served.
+	StaticPath = "/static/" + AssetVersion
+)
+
+// StaticFS returns the embedded assets.
+func StaticFS() fs.FS {
+	sub, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// Asset returns the URL of the embedded asset served under the base path.
+func Asset(basePath, name string) string {
+	return basePath + StaticPath + "/" + name
+}
+
+// assetVersion returns a short hash of every embedded asset.
+func assetVersion() string {
+	h := sha256.New()
+	_ = fs.WalkDir(static, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := static.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, _ = h.Write([]byte(path))
+		_, _ = h.Write(b)
+		return nil
+	})
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
@@ -0,0 +1,26 @@
+package page
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsset(t *testing.T) {
+	assert.Len(t, AssetVersion, 12)
+	assert.Equal(t, "/static/"+AssetVersion, StaticPath)
+
+	// An embedded asset is served under the base path.
+	assert.Equal(t, "/admin/cron"+StaticPath+"/README.md", Asset("/admin/cron", "README.md"))
+	assert.Equal(t, StaticPath+"/cronx.css", Asset("", "cronx.css"))
+}
+
+func TestStaticFS(t *testing.T) {
+	for _, name := range []string{"README.md", "cronx.css", "screenshot.js"} {
+		b, err := fs.ReadFile(StaticFS(), name)
+		require.NoError(t, err)
+		assert.NotEmpty(t, b)
+	}
+}
@@ -18,29 +18,133 @@ const historyTemplate = `
 	<meta http-equiv="X-UA-Compatible" content="IE=edge,chrome=1">
 	<meta name="viewport" content="width=device-width, initial-scale=1.0, maximum-scale=1.0">
 	<!-- Site Properties -->
-	<title>Cronx</title>
-	<link
-	   rel="stylesheet"
-	   type="text/css"
-	   href="https://cdn.jsdelivr.net/npm/semantic-ui@2.4.2/dist/semantic.min.css">
-	<script
-	   src="https://code.jquery.com/jquery-3.1.1.min.js"
-	   integrity="sha256-hVVnYaiADRTO2PzUGmuLJr8BLUSjGIZsDYGmIJLv2b8="
-	   crossorigin="anonymous"></script>
-	<script
-	   src="https://cdn.jsdelivr.net/npm/semantic-ui@2.4.2/dist/semantic.min.js"
-	   crossorigin="anonymous"></script>
-	<script
-	   src="https://cdnjs.cloudflare.com/ajax/libs/html2canvas/0.5.0-beta4/html2canvas.min.js"
-	   integrity="sha512-OqcrADJLG261FZjar4Z6c4CfLqd861A3yPNMb+vRQ2JwzFT49WT4lozrh3bcKxHxtDTgNiqgYbEUStzvZQRfgQ=="
-	   crossorigin="anonymous"></script>
-	<script src="https://cdn.jsdelivr.net/npm/canvas2image@1.0.5/canvas2image.min.js"></script>
+	<title>{{block "title" .}}Cronx{{end}}</title>
+	<link rel="stylesheet" type="text/css" href="{{asset .BasePath "cronx.css"}}">
+	<script src="{{asset .BasePath "screenshot.js"}}"></script>
 	<script type='text/javascript'>
-		function screenshot() {
-			html2canvas(document.querySelector('#data_table')).then(function(canvas) {
-				Canvas2Image.saveAsPNG(canvas, canvas.width, canvas.height);
+		// Label color, label text, and label icon of each status, the same as the rows rendered by the server.
+		var statusStyles = {
+			SUCCESS: {row: 'positive', label: 'green'},
+			RUNNING: {row: 'warning', label: 'yellow'},
+			ERROR: {row: 'error', label: 'red', text: 'FAILED'},
+			ABANDONED: {row: 'error', label: 'orange'},
+			BROKEN: {row: 'error', label: 'orange'},
+			PANIC: {row: 'error', label: 'red', icon: 'bomb'},
+			CANCELLED: {row: 'warning', label: 'grey', icon: 'ban'},
+			SKIPPED: {row: 'warning', label: 'grey', icon: 'forward'}
+		};
+
+		function statusLabel(status) {
+			var style = statusStyles[status] || {icon: 'arrow up'};
+			var label = document.createElement('div');
+			label.className = style.label ? 'ui ' + style.label + ' label' : 'ui label';
+			if (style.icon) {
+				var icon = document.createElement('i');
+				icon.className = style.icon + ' icon';
+				label.appendChild(icon);
+			}
+			label.appendChild(document.createTextNode(style.text || status));
+			return label;
+		}
+
+		function formatTime(value) {
+			var t = new Date(value);
+			var pad = function(n) {
+				return String(n).padStart(2, '0');
+			};
+			return t.getFullYear() + '-' + pad(t.getMonth() + 1) + '-' + pad(t.getDate()) + ' ' +
+				pad(t.getHours()) + ':' + pad(t.getMinutes()) + ':' + pad(t.getSeconds());
+		}
+
+		// Update the row of the run from the event, the rest of the page is left as it is.
+		// A run that is not on the page yet is inserted on top, as long as the page shows the newest runs.
+		function update(event) {
+			var data = JSON.parse(event.data);
+			var row = document.querySelector('tr[data-history-id="' + data.history_id + '"]') || insert(data);
+			if (!row) {
+				return;
+			}
+
+			row.className = (statusStyles[data.status] || {}).row || '';
+			row.querySelector('[data-status]').replaceChildren(statusLabel(data.status));
+			var latency = row.querySelector('[data-latency]');
+			if (event.type === 'RUN_FINISHED') {
+				row.querySelector('[data-finished]').textContent = formatTime(data.time);
+				latency.textContent = data.latency;
+			} else {
+				var elapsed = document.createElement('span');
+				elapsed.dataset.started = String(Date.parse(data.started_at));
+				latency.replaceChildren(elapsed);
+				tick();
+			}
+		}
+
+		// Insert the row of a new run on top.
+		// Returns null if the page does not show the newest runs, e.g. the next page or another sort.
+		function insert(data) {
+			var body = document.querySelector('#data_table tbody');
+			if (!body.hasAttribute('data-newest')) {
+				return null;
+			}
+			body.querySelectorAll('tr:not([data-history-id])').forEach(function(el) {
+				el.remove();
 			});
+
+			var cell = function(text, attr) {
+				var td = document.createElement('td');
+				td.textContent = text;
+				if (attr) {
+					td.setAttribute(attr, '');
+				}
+				return td;
+			};
+			var row = document.createElement('tr');
+			row.dataset.historyId = data.history_id;
+			var name = cell(data.key);
+			name.className = 'left aligned';
+			var latency = cell('');
+			var span = document.createElement('span');
+			span.setAttribute('data-latency', '');
+			latency.appendChild(span);
+			row.append(
+				cell(data.history_id),
+				name,
+				cell('', 'data-status'),
+				cell(formatTime(data.started_at)),
+				cell('', 'data-finished'),
+				latency
+			);
+
+			// The extra cells of a custom template are left empty until the page is reloaded.
+			var columns = document.querySelectorAll('#data_table thead tr:last-child th').length;
+			while (row.children.length < columns) {
+				row.appendChild(document.createElement('td'));
+			}
+
+			body.insertBefore(row, body.firstChild);
+			return row;
 		}
+
+		// Show the elapsed time of the running jobs.
+		function tick() {
+			document.querySelectorAll('[data-started]').forEach(function(el) {
+				var seconds = Math.max(0, Math.floor((Date.now() - Number(el.dataset.started)) / 1000));
+				var minutes = Math.floor(seconds / 60);
+				el.textContent = 'elapsed ' + (minutes > 0 ? minutes + 'm' : '') + (seconds % 60) + 's';
+			});
+		}
+
+		document.addEventListener('DOMContentLoaded', function() {
+			tick();
+			setInterval(tick, 1000);
+			if (!window.EventSource) {
+				return;
+			}
+			var source = new EventSource({{.BasePath}} + '/api/events');
+			['RUN_STARTED', 'RUN_FINISHED'].forEach(function(name) {
+				source.addEventListener(name, update);
+			});
+		});
 	</script>
 	<style>
         body > .ui.container {
@@ -48,16 +152,19 @@ const historyTemplate = `
             padding-bottom: 3em;
         }
 	</style>
-	<title>Cronx</title>
+	{{block "head" .}}{{end}}
 </head>
 <body>
 <div class="ui container">
+	{{block "header" .}}{{end}}
 	<div class="ui left fixed vertical stackable inverted main menu">
 		<div class="header item">
-			<i class="stopwatch icon"></i>
-			Cronx
+			{{block "brand" .}}
+				<i class="stopwatch icon"></i>
+				Cronx
+			{{end}}
 		</div>
-		<a class="item" href="/jobs">
+		<a class="item" href="{{.BasePath}}/jobs">
 			<i class="tasks icon"></i>
 			Jobs
 		</a>
@@ -130,18 +237,25 @@ const historyTemplate = `
                         {{end}}
 				>Latency
 				</th>
+				{{block "history_columns" .}}{{end}}
 			</tr>
 			</thead>
-			<tbody>
+			<tbody{{if and (not .Pagination.PreviousURI) (or (not .Sort.Query) (and (eq (len .Sort.Columns) 1) (eq (index .Sort.Columns "id") "DESC")))}} data-newest{{end}}>
             {{if not .Data}}
 				<tr>
 					<td colspan="6" class="center aligned"><b><i>No records found.</i></b></td>
 				</tr>
             {{end}}
             {{range .Data}}
-				<tr
+				<tr data-history-id="{{.ID}}"
                         {{if eq .Status "SUCCESS"}} class="positive"
+                        {{else if eq .Status "RUNNING"}} class="warning"
                         {{else if eq .Status "ERROR"}} class="error"
+                        {{else if eq .Status "ABANDONED"}} class="error"
+                        {{else if eq .Status "BROKEN"}} class="error"
+                        {{else if eq .Status "PANIC"}} class="error"
+                        {{else if eq .Status "CANCELLED"}} class="warning"
+                        {{else if eq .Status "SKIPPED"}} class="warning"
                         {{end}}
 				>
 					<td>{{.ID}}</td>
@@ -152,7 +266,7 @@ const historyTemplate = `
                             {{.Name}}
                         {{end}}
 
-                        {{if eq .Status "ERROR"}}
+                        {{if or (eq .Status "ERROR") (eq .Status "PANIC") (eq .Status "SKIPPED")}}
 							<br/>
 							<br/>
 							err = {{.Error.Err}}<br/>
@@ -174,17 +288,74 @@ const historyTemplate = `
                             {{if .Error.OpTraces}}
 								op_traces = {{.Error.OpTraces}}<br/>
                             {{end}}
+                            {{if .Error.Stack}}
+								<details>
+									<summary>stack ({{len .Error.Stack}} frames)</summary>
+									<pre>{{range .Error.Stack}}{{.}}
+{{end}}</pre>
+								</details>
+                            {{end}}
+                        {{end}}
+
+                        {{if or .Result.Counters .Result.Values}}
+							<br/>
+							<br/>
+                            {{range $key, $val := .Result.Counters}}
+                                {{$key}} = {{$val}}<br/>
+                            {{end}}
+                            {{range $key, $val := .Result.Values}}
+                                {{$key}} = {{$val}}<br/>
+                            {{end}}
+                        {{end}}
+
+                        {{if .Logs.Lines}}
+							<details>
+								<summary>
+									logs ({{len .Logs.Lines}} lines{{if .Logs.Truncated}}, truncated{{end}})
+								</summary>
+								<pre>{{range .Logs.Lines}}{{.Time.Format "15:04:05.000"}} [{{.Level}}] {{.Message}}{{if .Fields}} {{.Fields}}{{end}}
+{{end}}</pre>
+							</details>
                         {{end}}
 					</td>
-					<td>
+					<td data-status>
                         {{if eq .Status "SUCCESS"}}
 							<div class="ui green label">
 								SUCCESS
 							</div>
+                        {{else if eq .Status "RUNNING"}}
+							<div class="ui yellow label">
+								RUNNING
+							</div>
                         {{else if eq .Status "ERROR"}}
 							<div class="ui red label">
 								FAILED
 							</div>
+                        {{else if eq .Status "ABANDONED"}}
+							<div class="ui orange label">
+								ABANDONED
+							</div>
+                        {{else if eq .Status "BROKEN"}}
+							<div class="ui orange label">
+								BROKEN
+							</div>
+                        {{else if eq .Status "PANIC"}}
+							<div class="ui red label">
+								<i class="bomb icon"></i>
+								PANIC
+							</div>
+                        {{else if eq .Status "CANCELLED"}}
+							<div class="ui grey label">
+								<i class="ban icon"></i>
+								CANCELLED
+							</div>
+							<br/>
+							by {{.Metadata.CancelledBy}}
+                        {{else if eq .Status "SKIPPED"}}
+							<div class="ui grey label">
+								<i class="forward icon"></i>
+								SKIPPED
+							</div>
                         {{else}}
 							<div class="ui label">
 								<i class="arrow up icon"></i>
@@ -192,9 +363,32 @@ const historyTemplate = `
 							</div>
                         {{end}}
 					</td>
-					<td>{{.StartedAt.Format "2006-01-02 15:04:05"}}</td>
-					<td>{{.FinishedAt.Format "2006-01-02 15:04:05"}}</td>
-					<td>{{.LatencyText}}</td>
+					<td>
+                        {{.StartedAt.Format "2006-01-02 15:04:05"}}
+                        {{if .Metadata.OffsetText}}
+							<br/>
+							offset +{{.Metadata.OffsetText}}
+                        {{end}}
+					</td>
+					<td data-finished>
+                        {{if not .FinishedAt.IsZero}}
+                            {{.FinishedAt.Format "2006-01-02 15:04:05"}}
+                        {{end}}
+					</td>
+					<td>
+						<span data-latency>
+                        {{if eq .Status "RUNNING"}}
+							<span data-started="{{.StartedAt.UnixMilli}}"></span>
+                        {{else}}
+                            {{.LatencyText}}
+                        {{end}}
+						</span>
+                        {{if .QueueWaitText}}
+							<br/>
+							queued {{.QueueWaitText}}
+                        {{end}}
+					</td>
+					{{block "history_cells" .}}{{end}}
 				</tr>
             {{end}}
 			</tbody>
@@ -220,6 +414,7 @@ const historyTemplate = `
 			</tfoot>
 		</table>
 	</div>
+	{{block "footer" .}}{{end}}
 </div>
 </body>
 </html>
@@ -233,7 +428,7 @@ var (
 
 func GetHistoryTemplate() (*template.Template, error) {
 	historyPageOnce.Do(func() {
-		t := template.New(historiesTemplateName)
+		t := template.New(historiesTemplateName).Funcs(funcs)
 		historyPage, historyPageError = t.Parse(historyTemplate)
 	})
 
@@ -0,0 +1,57 @@
+package page
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/rizalgowandy/cronx/storage"
+	"github.com/rizalgowandy/gdk/pkg/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHistoryTemplate(t *testing.T) {
+	tmpl, err := GetHistoryTemplate()
+	require.NoError(t, err)
+
+	data := struct {
+		Data       []storage.History
+		Pagination struct{ PreviousURI, NextURI *string }
+		Sort       pagination.Sort
+		BasePath   string
+	}{
+		Data: []storage.History{
+			{
+				ID:        1,
+				Name:      "payBill",
+				Status:    "RUNNING",
+				StartedAt: time.Now(),
+				Logs: storage.HistoryLogs{
+					Lines: []storage.LogLine{
+						{Time: time.Now(), Level: "info", Message: "bills paid"},
+					},
+				},
+			},
+		},
+		Sort:     pagination.Sort{Columns: map[string]string{}},
+		BasePath: "/admin/cron",
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, data))
+	assert.Contains(t, buf.String(), "bills paid")
+	assert.Contains(t, buf.String(), "RUNNING")
+	assert.Contains(t, buf.String(), `href="/admin/cron/jobs"`)
+	assert.Contains(t, buf.String(), `<tr data-history-id="1"`)
+	assert.Contains(t, buf.String(), `<td data-status>`)
+	assert.Contains(t, buf.String(), `<td data-finished>`)
+	assert.Contains(t, buf.String(), `<span data-latency>`)
+	assert.Contains(t, buf.String(), `<tbody data-newest>`)
+
+	previous := "/admin/cron/histories?page=1"
+	data.Pagination.PreviousURI = &previous
+	buf.Reset()
+	require.NoError(t, tmpl.Execute(&buf, data))
+	assert.Contains(t, buf.String(), "<tbody>")
+}
@@ -16,32 +16,129 @@ const jobsTemplate = `
 	<!-- Standard Meta -->
 	<meta charset="UTF-8">
 	<meta http-equiv="X-UA-Compatible" content="IE=edge,chrome=1">
-	<meta http-equiv="refresh" content="30"/>
 	<meta name="viewport" content="width=device-width, initial-scale=1.0, maximum-scale=1.0">
 	<!-- Site Properties -->
-	<title>Cronx</title>
-	<link
-	   rel="stylesheet"
-	   type="text/css"
-	   href="https://cdn.jsdelivr.net/npm/semantic-ui@2.4.2/dist/semantic.min.css">
-	<script
-	   src="https://code.jquery.com/jquery-3.1.1.min.js"
-	   integrity="sha256-hVVnYaiADRTO2PzUGmuLJr8BLUSjGIZsDYGmIJLv2b8="
-	   crossorigin="anonymous"></script>
-	<script
-	   src="https://cdn.jsdelivr.net/npm/semantic-ui@2.4.2/dist/semantic.min.js"
-	   crossorigin="anonymous"></script>
-	<script
-	   src="https://cdnjs.cloudflare.com/ajax/libs/html2canvas/0.5.0-beta4/html2canvas.min.js"
-	   integrity="sha512-OqcrADJLG261FZjar4Z6c4CfLqd861A3yPNMb+vRQ2JwzFT49WT4lozrh3bcKxHxtDTgNiqgYbEUStzvZQRfgQ=="
-	   crossorigin="anonymous"></script>
-	<script src="https://cdn.jsdelivr.net/npm/canvas2image@1.0.5/canvas2image.min.js"></script>
+	<title>{{block "title" .}}Cronx{{end}}</title>
+	<link rel="stylesheet" type="text/css" href="{{asset .BasePath "cronx.css"}}">
+	<script src="{{asset .BasePath "screenshot.js"}}"></script>
 	<script type='text/javascript'>
-		function screenshot() {
-			html2canvas(document.querySelector('#data_table')).then(function(canvas) {
-				Canvas2Image.saveAsPNG(canvas, canvas.width, canvas.height);
+		function resetBreaker(id) {
+			fetch({{.BasePath}} + '/api/jobs/' + id + '/breaker/reset', {method: 'POST'}).then(function(resp) {
+				var breaker = document.querySelector('tr[data-entry-id="' + id + '"] [data-breaker]');
+				if (resp.ok && breaker) {
+					breaker.hidden = true;
+				}
 			});
 		}
+
+		function cancelJob(id) {
+			if (!confirm('Cancel the current run?')) {
+				return;
+			}
+			fetch({{.BasePath}} + '/api/jobs/' + id + '/cancel', {method: 'POST'});
+		}
+
+		// Row class, label color, and label icon of each status, the same as the rows rendered by the server.
+		var statusStyles = {
+			RUNNING: {row: 'warning', label: 'yellow'},
+			QUEUED: {row: 'warning', label: 'blue'},
+			SUCCESS: {row: 'positive', label: 'green'},
+			COMPLETED: {row: 'positive', label: 'teal'},
+			DOWN: {row: 'error', label: 'red'},
+			ERROR: {row: 'error', label: 'red'},
+			PANIC: {row: 'error', label: 'red', icon: 'bomb'},
+			CANCELLED: {row: 'warning', label: 'grey', icon: 'ban'},
+			SKIPPED: {row: 'warning', label: 'grey', icon: 'forward'},
+			BROKEN: {row: 'error', label: 'orange'}
+		};
+		var activeStatuses = ['RUNNING', 'QUEUED'];
+		var errorStatuses = ['ERROR', 'PANIC', 'CANCELLED', 'SKIPPED'];
+
+		function statusLabel(status) {
+			var style = statusStyles[status] || {};
+			var label = document.createElement('div');
+			label.className = style.label ? 'ui ' + style.label + ' label' : 'ui label';
+			if (style.icon) {
+				var icon = document.createElement('i');
+				icon.className = style.icon + ' icon';
+				label.appendChild(icon);
+			}
+			label.appendChild(document.createTextNode(status));
+			return label;
+		}
+
+		// Format the time the same as the server, empty for the zero time.
+		function formatTime(value) {
+			var t = new Date(value);
+			if (t.getFullYear() <= 1) {
+				return '';
+			}
+			var pad = function(n) {
+				return String(n).padStart(2, '0');
+			};
+			return t.getFullYear() + '-' + pad(t.getMonth() + 1) + '-' + pad(t.getDate()) + ' ' +
+				pad(t.getHours()) + ':' + pad(t.getMinutes()) + ':' + pad(t.getSeconds());
+		}
+
+		// Update the row of the job from the event, the rest of the page is left as it is.
+		function update(event) {
+			var data = JSON.parse(event.data);
+			var row = document.querySelector('tr[data-entry-id="' + data.entry_id + '"]');
+			if (!row) {
+				return;
+			}
+
+			var style = statusStyles[data.status] || {};
+			row.className = row.hasAttribute('data-overdue') ? 'error' : (style.row || '');
+			row.querySelector('[data-status]').replaceChildren(statusLabel(data.status));
+
+			var active = activeStatuses.indexOf(data.status) >= 0;
+			row.querySelector('[data-cancel]').hidden = !active;
+
+			var error = row.querySelector('[data-error]');
+			error.hidden = errorStatuses.indexOf(data.status) < 0;
+			error.querySelector('span').textContent = data.error;
+
+			row.querySelector('[data-prev-run]').textContent = formatTime(data.prev_run);
+			var next = row.querySelector('[data-next-run]');
+			if (next) {
+				next.replaceChildren(document.createTextNode(formatTime(data.next_run)));
+				if (data.next_offset) {
+					next.append(document.createElement('br'), 'offset +' + data.next_offset);
+				}
+			}
+
+			var latency = row.querySelector('[data-latency]');
+			if (active) {
+				var elapsed = document.createElement('span');
+				elapsed.dataset.started = String(Date.parse(data.started_at));
+				latency.replaceChildren(elapsed);
+				tick();
+			} else {
+				latency.textContent = data.latency;
+			}
+		}
+
+		// Show the elapsed time of the running jobs.
+		function tick() {
+			document.querySelectorAll('[data-started]').forEach(function(el) {
+				var seconds = Math.max(0, Math.floor((Date.now() - Number(el.dataset.started)) / 1000));
+				var minutes = Math.floor(seconds / 60);
+				el.textContent = 'elapsed ' + (minutes > 0 ? minutes + 'm' : '') + (seconds % 60) + 's';
+			});
+		}
+
+		document.addEventListener('DOMContentLoaded', function() {
+			tick();
+			setInterval(tick, 1000);
+			if (!window.EventSource) {
+				return;
+			}
+			var source = new EventSource({{.BasePath}} + '/api/events');
+			['RUN_STARTED', 'RUN_FINISHED', 'STATUS_CHANGED'].forEach(function(name) {
+				source.addEventListener(name, update);
+			});
+		});
 	</script>
 	<style>
         body > .ui.container {
@@ -49,20 +146,23 @@ const jobsTemplate = `
             padding-bottom: 3em;
         }
 	</style>
-	<title>Cronx</title>
+	{{block "head" .}}{{end}}
 </head>
 <body>
 <div class="ui container">
+	{{block "header" .}}{{end}}
 	<div class="ui left fixed vertical stackable inverted main menu">
 		<div class="header item">
-			<i class="stopwatch icon"></i>
-			Cronx
+			{{block "brand" .}}
+				<i class="stopwatch icon"></i>
+				Cronx
+			{{end}}
 		</div>
 		<a class="item active" href="javascript:window.location.reload()">
 			<i class="tasks icon"></i>
 			Jobs
 		</a>
-		<a class="item" href="/histories">
+		<a class="item" href="{{.BasePath}}/histories">
 			<i class="history icon"></i>
 			Histories
 		</a>
@@ -73,7 +173,7 @@ const jobsTemplate = `
 			</button>
 		</div>
 	</div>
-	<div class="ui five steps">
+	<div class="ui twelve steps">
 		<div class="step">
 			<i class="arrow down icon"></i>
 			<div class="content">
@@ -88,6 +188,13 @@ const jobsTemplate = `
 				<div class="description">Job has just been created</div>
 			</div>
 		</div>
+		<div class="step">
+			<i class="hourglass start icon"></i>
+			<div class="content">
+				<div class="title">Queued</div>
+				<div class="description">Job is waiting for a worker</div>
+			</div>
+		</div>
 		<div class="step">
 			<i class="sync icon"></i>
 			<div class="content">
@@ -102,6 +209,13 @@ const jobsTemplate = `
 				<div class="description">Job succeeds on the last run, waiting for next run</div>
 			</div>
 		</div>
+		<div class="step">
+			<i class="flag checkered icon"></i>
+			<div class="content">
+				<div class="title">Completed</div>
+				<div class="description">Job succeeds on its only run, never runs again</div>
+			</div>
+		</div>
 		<div class="step">
 			<i class="attention icon"></i>
 			<div class="content">
@@ -109,6 +223,41 @@ const jobsTemplate = `
 				<div class="description">Job fails on the prev run</div>
 			</div>
 		</div>
+		<div class="step">
+			<i class="bomb icon"></i>
+			<div class="content">
+				<div class="title">Panic</div>
+				<div class="description">Job panics on the prev run</div>
+			</div>
+		</div>
+		<div class="step">
+			<i class="ban icon"></i>
+			<div class="content">
+				<div class="title">Cancelled</div>
+				<div class="description">Job is cancelled by an operator on the prev run</div>
+			</div>
+		</div>
+		<div class="step">
+			<i class="forward icon"></i>
+			<div class="content">
+				<div class="title">Skipped</div>
+				<div class="description">Job is skipped by an interceptor on the prev run, e.g. rate limit</div>
+			</div>
+		</div>
+		<div class="step">
+			<i class="bell icon"></i>
+			<div class="content">
+				<div class="title">Overdue</div>
+				<div class="description">Job has not succeeded within its success window</div>
+			</div>
+		</div>
+		<div class="step">
+			<i class="pause icon"></i>
+			<div class="content">
+				<div class="title">Broken</div>
+				<div class="description">Job fails too many times in a row, runs are skipped</div>
+			</div>
+		</div>
 	</div>
 	<div id="data_table">
 		<table class="ui sortable selectable center aligned celled table">
@@ -150,6 +299,7 @@ const jobsTemplate = `
                         {{end}}
 				>Latency
 				</th>
+				{{block "job_columns" .}}{{end}}
 			</tr>
 			</thead>
 			<tbody>
@@ -159,11 +309,18 @@ const jobsTemplate = `
 				</tr>
             {{end}}
             {{range .Data}}
-				<tr
-                        {{if eq .Job.Status "RUNNING"}} class="warning"
+				<tr data-entry-id="{{.ID}}"
+                        {{if .Job.Overdue}} data-overdue class="error"
+                        {{else if eq .Job.Status "RUNNING"}} class="warning"
+                        {{else if eq .Job.Status "QUEUED"}} class="warning"
                         {{else if eq .Job.Status "SUCCESS"}} class="positive"
+                        {{else if eq .Job.Status "COMPLETED"}} class="positive"
                         {{else if eq .Job.Status "DOWN"}} class="error"
                         {{else if eq .Job.Status "ERROR"}} class="error"
+                        {{else if eq .Job.Status "PANIC"}} class="error"
+                        {{else if eq .Job.Status "CANCELLED"}} class="warning"
+                        {{else if eq .Job.Status "SKIPPED"}} class="warning"
+                        {{else if eq .Job.Status "BROKEN"}} class="error"
                         {{end}}
 				>
 					<td>{{.ID}}</td>
@@ -172,17 +329,43 @@ const jobsTemplate = `
                             {{.Job.Name}} ({{.Job.Wave}}/{{.Job.TotalWave}})
                         {{else}}
                             {{.Job.Name}}
+                        {{end}}
+                        {{if .Job.Schedule}}
+							<br/>
+							<small>{{.Job.Schedule}}</small>
+                        {{end}}
+
+                        {{if or .Job.Result.Counters .Job.Result.Values}}
+							<br/>
+							<br/>
+                            {{$trends := .Job.ResultTrends}}
+                            {{range $key, $val := .Job.Result.Counters}}
+                                {{$key}} = {{$val}}
+                                {{with index $trends $key}}({{printf "%+d" .}}){{end}}<br/>
+                            {{end}}
+                            {{range $key, $val := .Job.Result.Values}}
+                                {{$key}} = {{$val}}<br/>
+                            {{end}}
                         {{end}}
 					</td>
 					<td>
+						<span data-status>
                         {{if eq .Job.Status "RUNNING"}}
 							<div class="ui yellow label">
                                 {{.Job.Status}}
 							</div>
+                        {{else if eq .Job.Status "QUEUED"}}
+							<div class="ui blue label">
+                                {{.Job.Status}}
+							</div>
                         {{else if eq .Job.Status "SUCCESS"}}
 							<div class="ui green label">
                                 {{.Job.Status}}
 							</div>
+                        {{else if eq .Job.Status "COMPLETED"}}
+							<div class="ui teal label">
+                                {{.Job.Status}}
+							</div>
                         {{else if eq .Job.Status "DOWN"}}
 							<div class="ui red label">
                                 {{.Job.Status}}
@@ -191,40 +374,108 @@ const jobsTemplate = `
 							<div class="ui red label">
                                 {{.Job.Status}}
 							</div>
+                        {{else if eq .Job.Status "PANIC"}}
+							<div class="ui red label">
+								<i class="bomb icon"></i>
+                                {{.Job.Status}}
+							</div>
+                        {{else if eq .Job.Status "BROKEN"}}
+							<div class="ui orange label">
+                                {{.Job.Status}}
+							</div>
+                        {{else if eq .Job.Status "CANCELLED"}}
+							<div class="ui grey label">
+								<i class="ban icon"></i>
+                                {{.Job.Status}}
+							</div>
+                        {{else if eq .Job.Status "SKIPPED"}}
+							<div class="ui grey label">
+								<i class="forward icon"></i>
+                                {{.Job.Status}}
+							</div>
                         {{else}}
 							<div class="ui label">
                                 {{.Job.Status}}
 							</div>
                         {{end}}
-					</td>
-					<td>
-                        {{if eq .Job.Status "ERROR"}}
-                            {{if not .Prev.IsZero}}
-                                {{.Prev.Format "2006-01-02 15:04:05"}}
-                            {{end}}
+						</span>
+						<span data-cancel{{if not (or (eq .Job.Status "RUNNING") (eq .Job.Status "QUEUED"))}} hidden{{end}}>
 							<br/>
-                            {{.Job.Error}}
-                        {{else}}
-                            {{if not .Prev.IsZero}}
-                                {{.Prev.Format "2006-01-02 15:04:05"}}
+							<br/>
+							<button class="ui mini red button" onclick="cancelJob({{.ID}})">Cancel</button>
+						</span>
+                        {{if .Job.Panics}}
+							<br/>
+							<br/>
+							panics = {{.Job.Panics}}<br/>
+                        {{end}}
+                        {{if or (eq .Job.Breaker "OPEN") (eq .Job.Breaker "HALF_OPEN")}}
+							<span data-breaker>
+								<br/>
+								<br/>
+								breaker = {{.Job.Breaker}}<br/>
+                                {{if eq .Job.Breaker "OPEN"}}
+									retry at {{.Job.BreakerRetryAt.Format "2006-01-02 15:04:05"}}<br/>
+                                {{end}}
+								<button class="ui mini button" onclick="resetBreaker({{.ID}})">Reset</button>
+							</span>
+                        {{end}}
+                        {{if .Job.Overdue}}
+							<div class="ui orange label" title="Expected to succeed every {{.Job.SuccessWindow}}">
+								OVERDUE
+							</div>
+                            {{if not .Job.LastSuccess.IsZero}}
+								<br/>
+								Last success {{.Job.LastSuccess.Format "2006-01-02 15:04:05"}}
                             {{end}}
                         {{end}}
 					</td>
 					<td>
+						<span data-prev-run>
+                        {{if not .Prev.IsZero}}
+                            {{.Prev.Format "2006-01-02 15:04:05"}}
+                        {{end}}
+						</span>
+						<span data-error{{if not (or (eq .Job.Status "ERROR") (eq .Job.Status "PANIC") (eq .Job.Status "CANCELLED") (eq .Job.Status "SKIPPED"))}} hidden{{end}}>
+							<br/>
+							<span>{{.Job.Error}}</span>
+						</span>
+					</td>
+					<td>
                         {{if eq .Job.Status "DOWN"}}
                             {{.Job.Error}}
                         {{else}}
+							<span data-next-run>
                             {{if not .Next.IsZero}}
                                 {{.Next.Format "2006-01-02 15:04:05"}}
+                                {{if .NextOffset}}
+									<br/>
+									offset +{{.NextOffset}}
+                                {{end}}
                             {{end}}
+							</span>
+                        {{end}}
+					</td>
+					<td>
+						<span data-latency>
+                        {{if or (eq .Job.Status "RUNNING") (eq .Job.Status "QUEUED")}}
+							<span data-started="{{.Job.StartedAt.UnixMilli}}"></span>
+                        {{else}}
+                            {{.Job.Latency}}
+                        {{end}}
+						</span>
+                        {{if .Job.QueueWait}}
+							<br/>
+							queued {{.Job.QueueWait}}
                         {{end}}
 					</td>
-					<td>{{.Job.Latency}}</td>
+					{{block "job_cells" .}}{{end}}
 				</tr>
             {{end}}
 			</tbody>
 		</table>
 	</div>
+	{{block "footer" .}}{{end}}
 </div>
 </body>
 </html>
@@ -238,7 +489,7 @@ var (
 
 func GetJobsPageTemplate() (*template.Template, error) {
 	jobsPageOnce.Do(func() {
-		t := template.New(jobsTemplateName)
+		t := template.New(jobsTemplateName).Funcs(funcs)
 		jobsPage, jobsPageError = t.Parse(jobsTemplate)
 	})
 
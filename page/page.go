@@ -1,7 +1,17 @@
 package page
 
+import (
+	"html/template"
+)
+
 // List of all available page templates.
 const (
 	jobsTemplateName      = "jobs.html"
 	historiesTemplateName = "histories.html"
 )
+
+// funcs are the functions available in the page templates.
+var funcs = template.FuncMap{
+	// asset returns the URL of an embedded asset, e.g. {{asset .BasePath "cronx.css"}}.
+	"asset": Asset,
+}
@@ -0,0 +1,88 @@
+/*
+ * Screenshot of the dashboard.
+ * The element is drawn into a canvas through an SVG image, together with the stylesheets of the page,
+ * then downloaded as a PNG image.
+ */
+(function() {
+	// Return the rules of every stylesheet of the page.
+	function styles() {
+		var rules = [];
+		Array.prototype.forEach.call(document.styleSheets, function(sheet) {
+			try {
+				Array.prototype.forEach.call(sheet.cssRules, function(rule) {
+					rules.push(rule.cssText);
+				});
+			} catch (e) {
+				// The rules of a stylesheet from another origin can't be read.
+			}
+		});
+		return rules.join('\n');
+	}
+
+	// Return an SVG image containing a copy of the element.
+	function image(element) {
+		var width = element.scrollWidth;
+		var height = element.scrollHeight;
+
+		var body = document.createElement('div');
+		body.setAttribute('xmlns', 'http://www.w3.org/1999/xhtml');
+		body.style.width = width + 'px';
+		body.style.background = '#ffffff';
+		var style = document.createElement('style');
+		style.textContent = styles();
+		body.appendChild(style);
+		body.appendChild(element.cloneNode(true));
+
+		var svg = '<svg xmlns="http://www.w3.org/2000/svg" width="' + width + '" height="' + height + '">' +
+			'<foreignObject width="100%" height="100%">' + new XMLSerializer().serializeToString(body) +
+			'</foreignObject></svg>';
+		return {
+			src: 'data:image/svg+xml;charset=utf-8,' + encodeURIComponent(svg),
+			width: width,
+			height: height
+		};
+	}
+
+	function download(href, name) {
+		var link = document.createElement('a');
+		link.href = href;
+		link.download = name;
+		document.body.appendChild(link);
+		link.click();
+		link.remove();
+	}
+
+	// Download the element as a PNG image, the element is #data_table by default.
+	window.screenshot = function(selector) {
+		var element = document.querySelector(selector || '#data_table');
+		if (!element) {
+			return;
+		}
+
+		var svg = image(element);
+		var name = 'cronx-' + new Date().toISOString().replace(/[:.]/g, '-');
+		var img = new Image();
+		img.onload = function() {
+			var scale = window.devicePixelRatio || 1;
+			var canvas = document.createElement('canvas');
+			canvas.width = svg.width * scale;
+			canvas.height = svg.height * scale;
+			var ctx = canvas.getContext('2d');
+			ctx.scale(scale, scale);
+			ctx.drawImage(img, 0, 0);
+			try {
+				canvas.toBlob(function(blob) {
+					var url = URL.createObjectURL(blob);
+					download(url, name + '.png');
+					setTimeout(function() {
+						URL.revokeObjectURL(url);
+					});
+				}, 'image/png');
+			} catch (e) {
+				// A browser that taints the canvas drawn from an SVG image gets the SVG image instead.
+				download(svg.src, name + '.svg');
+			}
+		};
+		img.src = svg.src;
+	};
+})();
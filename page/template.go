@@ -0,0 +1,115 @@
+package page
+
+import (
+	"html/template"
+	"io/fs"
+	"sync"
+)
+
+// Override overrides or extends the named templates of a page.
+// Named templates of the pages, defined with an empty or default content:
+//   - title			=> the title of the browser tab.
+//   - head			=> extra elements at the end of <head>, e.g. stylesheets.
+//   - brand			=> the header of the menu.
+//   - header			=> the top of the page, e.g. a company header.
+//   - footer			=> the bottom of the page.
+//   - job_columns		=> extra <th> of the jobs table, the dot is StatusPageData.
+//   - job_cells		=> extra <td> of a job row, the dot is StatusData.
+//   - history_columns	=> extra <th> of the histories table, the dot is HistoryPageData.
+//   - history_cells	=> extra <td> of a history row, the dot is storage.History.
+//
+// Overriding jobs.html or histories.html replaces the whole page.
+type Override func(t *template.Template) (*template.Template, error)
+
+// OverrideFS parses the files of fsys matching the patterns,
+// every {{define "name"}} in the files replaces the named template of the pages.
+func OverrideFS(fsys fs.FS, patterns ...string) Override {
+	return func(t *template.Template) (*template.Template, error) {
+		return t.ParseFS(fsys, patterns...)
+	}
+}
+
+// OverrideTemplate adds every named template associated with tmpl to the pages,
+// replacing the named template of the same name.
+// The tmpl must not have been executed.
+func OverrideTemplate(tmpl *template.Template) Override {
+	return func(t *template.Template) (*template.Template, error) {
+		for _, v := range tmpl.Templates() {
+			if v.Tree == nil || v.Tree.Root == nil {
+				continue
+			}
+			if _, err := t.AddParseTree(v.Name(), v.Tree); err != nil {
+				return nil, err
+			}
+		}
+		return t, nil
+	}
+}
+
+// NewTemplates creates the page templates with the functions and overrides.
+// The functions are available to the overrides on top of the built-in functions.
+func NewTemplates(funcs template.FuncMap, overrides ...Override) *Templates {
+	return &Templates{
+		funcs:     funcs,
+		overrides: overrides,
+	}
+}
+
+// Templates are the page templates, parsed once on the first use.
+// A nil Templates returns the built-in page templates.
+type Templates struct {
+	funcs     template.FuncMap
+	overrides []Override
+
+	jobsOnce     sync.Once
+	jobs         *template.Template
+	jobsError    error
+	historyOnce  sync.Once
+	history      *template.Template
+	historyError error
+}
+
+// Jobs returns the template of the jobs page.
+func (t *Templates) Jobs() (*template.Template, error) {
+	if t.isDefault() {
+		return GetJobsPageTemplate()
+	}
+
+	t.jobsOnce.Do(func() {
+		t.jobs, t.jobsError = t.parse(jobsTemplateName, jobsTemplate)
+	})
+	return t.jobs, t.jobsError
+}
+
+// Histories returns the template of the histories page.
+func (t *Templates) Histories() (*template.Template, error) {
+	if t.isDefault() {
+		return GetHistoryTemplate()
+	}
+
+	t.historyOnce.Do(func() {
+		t.history, t.historyError = t.parse(historiesTemplateName, historyTemplate)
+	})
+	return t.history, t.historyError
+}
+
+// isDefault returns true if there is nothing to customize on the built-in page templates.
+func (t *Templates) isDefault() bool {
+	return t == nil || (len(t.funcs) == 0 && len(t.overrides) == 0)
+}
+
+// parse parses the page, then applies the overrides.
+func (t *Templates) parse(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Funcs(t.funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, override := range t.overrides {
+		tmpl, err = override(tmpl)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
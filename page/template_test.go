@@ -0,0 +1,94 @@
+package page
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rizalgowandy/cronx/storage"
+	"github.com/rizalgowandy/gdk/pkg/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type historyPageData struct {
+	Data       []storage.History
+	Pagination struct{ PreviousURI, NextURI *string }
+	Sort       pagination.Sort
+	BasePath   string
+}
+
+func TestTemplates_Default(t *testing.T) {
+	var nilTemplates *Templates
+	for _, v := range []*Templates{nilTemplates, NewTemplates(nil)} {
+		jobs, err := v.Jobs()
+		require.NoError(t, err)
+		want, _ := GetJobsPageTemplate()
+		assert.Same(t, want, jobs)
+
+		history, err := v.Histories()
+		require.NoError(t, err)
+		wantHistory, _ := GetHistoryTemplate()
+		assert.Same(t, wantHistory, history)
+	}
+}
+
+func TestTemplates_Histories(t *testing.T) {
+	fsys := fstest.MapFS{
+		"brand.gohtml": &fstest.MapFile{
+			Data: []byte(`{{define "brand"}}{{upper "acme"}}{{end}}`),
+		},
+		"columns.gohtml": &fstest.MapFile{
+			Data: []byte(`{{define "history_columns"}}<th>Owner</th>{{end}}` +
+				`{{define "history_cells"}}<td>owner of {{.Name}}</td>{{end}}`),
+		},
+	}
+	footer := template.Must(template.New("custom").Parse(
+		`{{define "footer"}}<p>Support: #ops</p>{{end}}{{define "title"}}Acme Cron{{end}}`,
+	))
+
+	templates := NewTemplates(
+		template.FuncMap{"upper": strings.ToUpper},
+		OverrideFS(fsys, "*.gohtml"),
+		OverrideTemplate(footer),
+	)
+	tmpl, err := templates.Histories()
+	require.NoError(t, err)
+
+	data := historyPageData{
+		Data: []storage.History{{ID: 1, Name: "payBill", Status: "SUCCESS"}},
+		Sort: pagination.Sort{Columns: map[string]string{}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, data))
+	assert.Contains(t, buf.String(), "ACME")
+	assert.NotContains(t, buf.String(), "stopwatch icon")
+	assert.Contains(t, buf.String(), "<th>Owner</th>")
+	assert.Contains(t, buf.String(), "<td>owner of payBill</td>")
+	assert.Contains(t, buf.String(), "<p>Support: #ops</p>")
+	assert.Equal(t, 1, strings.Count(buf.String(), "<title>"))
+	assert.Contains(t, buf.String(), "<title>Acme Cron</title>")
+
+	// The built-in template is left untouched.
+	builtin, err := GetHistoryTemplate()
+	require.NoError(t, err)
+	buf.Reset()
+	require.NoError(t, builtin.Execute(&buf, data))
+	assert.Contains(t, buf.String(), "stopwatch icon")
+	assert.NotContains(t, buf.String(), "Owner")
+	assert.Equal(t, 1, strings.Count(buf.String(), "<title>"))
+	assert.Contains(t, buf.String(), "<title>Cronx</title>")
+}
+
+func TestTemplates_Jobs(t *testing.T) {
+	templates := NewTemplates(nil, OverrideFS(fstest.MapFS{
+		"broken.gohtml": &fstest.MapFile{Data: []byte(`{{define "brand"}}{{unknown}}{{end}}`)},
+	}, "*.gohtml"))
+
+	_, err := templates.Jobs()
+	require.Error(t, err)
+	_, again := templates.Jobs()
+	assert.Equal(t, err, again)
+}
@@ -0,0 +1,347 @@
+package cronx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// maxCalendarLookup limits how many fire times are checked against the calendar,
+// so a calendar without any business day does not loop forever.
+const maxCalendarLookup = 1000
+
+// NewOnceSchedule creates a schedule that runs once at the given time.
+// The job is shown as COMPLETED after the run has succeeded.
+// A time that has already passed never runs.
+func NewOnceSchedule(at time.Time) *OnceSchedule {
+	return &OnceSchedule{at: at}
+}
+
+// OnceSchedule runs a job once at a specific time.
+type OnceSchedule struct {
+	at time.Time
+}
+
+// Next returns the time of the run if it has not passed, otherwise zero time to never run again.
+func (s *OnceSchedule) Next(t time.Time) time.Time {
+	if !s.at.After(t) {
+		return time.Time{}
+	}
+	return s.at.In(t.Location())
+}
+
+func (s *OnceSchedule) String() string {
+	return "once at " + s.at.Format("2006-01-02 15:04:05")
+}
+
+// NewFixedDelaySchedule creates a schedule that runs the job after the delay,
+// then again after the same delay has passed since the previous run has finished.
+// Unlike @every, the runs never overlap however long the run takes.
+func NewFixedDelaySchedule(delay time.Duration) *FixedDelaySchedule {
+	return &FixedDelaySchedule{
+		delay:    delay,
+		mu:       sync.Mutex{},
+		running:  false,
+		finished: time.Time{},
+	}
+}
+
+// FixedDelaySchedule runs a job a fixed delay after the previous run has finished.
+// The finish of a run is unknown when the next fire time is asked for,
+// so while the run is running, the schedule fires at the earliest possible time of the next run,
+// and the fire only runs the job once the delay has passed since the finish, see due.
+type FixedDelaySchedule struct {
+	delay time.Duration
+
+	mu sync.Mutex
+	// running determines if a run has started and not finished yet.
+	running bool
+	// finished is the finish time of the last run, zero if it has never run.
+	finished time.Time
+}
+
+// Next returns the time the delay has passed since the last run has finished,
+// or the time after the delay while the run is running.
+func (s *FixedDelaySchedule) Next(t time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if next := s.finished.Add(s.delay); !s.running && !s.finished.IsZero() && next.After(t) {
+		return next
+	}
+	// The run finishes after t at the earliest.
+	return t.Add(s.delay)
+}
+
+// due determines if the job should run at the given time, and marks the run as running if so.
+// A fire before the delay has passed since the last finish only reschedules the next run.
+func (s *FixedDelaySchedule) due(t time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running || (!s.finished.IsZero() && t.Before(s.finished.Add(s.delay))) {
+		return false
+	}
+	s.running = true
+	return true
+}
+
+// finish records the finish time of the run, the next run is counted from it.
+func (s *FixedDelaySchedule) finish(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running = false
+	s.finished = t
+}
+
+func (s *FixedDelaySchedule) String() string {
+	return fmt.Sprintf("%s after the previous run", s.delay)
+}
+
+// CalendarItf determines which days the jobs are allowed to run.
+type CalendarItf interface {
+	IsBusinessDay(t time.Time) bool
+}
+
+// NewCalendar creates a calendar where the weekends and the given holidays are not business days.
+// Only the date of the holidays is used.
+func NewCalendar(holidays ...time.Time) *Calendar {
+	dates := make(map[string]bool, len(holidays))
+	for _, v := range holidays {
+		dates[v.Format(time.DateOnly)] = true
+	}
+	return &Calendar{holidays: dates}
+}
+
+// Calendar is a calendar with Saturday and Sunday as the weekends.
+type Calendar struct {
+	holidays map[string]bool
+}
+
+// IsBusinessDay returns true if the date is neither a weekend nor a holiday.
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return !c.holidays[t.Format(time.DateOnly)]
+}
+
+// NewBusinessDaySchedule creates a schedule that skips the fire times of the schedule
+// that do not fall on a business day of the calendar.
+func NewBusinessDaySchedule(schedule cron.Schedule, calendar CalendarItf) *BusinessDaySchedule {
+	return &BusinessDaySchedule{
+		schedule: schedule,
+		calendar: calendar,
+	}
+}
+
+// BusinessDaySchedule runs a job on the business days only.
+type BusinessDaySchedule struct {
+	schedule cron.Schedule
+	calendar CalendarItf
+}
+
+// Next returns the next fire time of the schedule that falls on a business day.
+func (s *BusinessDaySchedule) Next(t time.Time) time.Time {
+	next := t
+	for range maxCalendarLookup {
+		next = s.schedule.Next(next)
+		if next.IsZero() || s.calendar.IsBusinessDay(next) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+func (s *BusinessDaySchedule) String() string {
+	return describeSchedule(s.schedule) + " on business days"
+}
+
+// NewLastBusinessDaySchedule creates a schedule that runs on the last business day of every month
+// at the given hour and minute.
+func NewLastBusinessDaySchedule(hour, minute int, calendar CalendarItf) *LastBusinessDaySchedule {
+	return &LastBusinessDaySchedule{
+		hour:     hour,
+		minute:   minute,
+		calendar: calendar,
+	}
+}
+
+// LastBusinessDaySchedule runs a job on the last business day of every month.
+type LastBusinessDaySchedule struct {
+	hour     int
+	minute   int
+	calendar CalendarItf
+}
+
+// Next returns the last business day of the month at the configured time after the given time.
+func (s *LastBusinessDaySchedule) Next(t time.Time) time.Time {
+	for month := range maxCalendarLookup {
+		// Day 0 of the next month is the last day of the month.
+		day := time.Date(t.Year(), t.Month()+time.Month(month)+1, 0, s.hour, s.minute, 0, 0, t.Location())
+		for day.Day() > 1 && !s.calendar.IsBusinessDay(day) {
+			day = day.AddDate(0, 0, -1)
+		}
+		if s.calendar.IsBusinessDay(day) && day.After(t) {
+			return day
+		}
+	}
+	return time.Time{}
+}
+
+func (s *LastBusinessDaySchedule) String() string {
+	return fmt.Sprintf("last business day of the month at %02d:%02d", s.hour, s.minute)
+}
+
+// describeSchedule returns a human-readable description of the schedule.
+func describeSchedule(schedule cron.Schedule) string {
+	switch s := schedule.(type) {
+	case fmt.Stringer:
+		return s.String()
+	case cron.ConstantDelaySchedule:
+		return "@every " + s.Delay.String()
+	case *cron.SpecSchedule:
+		return describeSpec(s)
+	default:
+		return "custom schedule"
+	}
+}
+
+// List of the names used to describe the fields of a spec schedule.
+var (
+	monthNames   = []string{"", "Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+	weekdayNames = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+)
+
+// describeSpec describes the spec schedule,
+// e.g. "0 30 9 * * 1-5" as "at 09:30 on Mon-Fri", and "0 */15 * * * *" as "at minute 0,15,30,45 of every hour".
+func describeSpec(s *cron.SpecSchedule) string {
+	desc := describeSpecTime(s)
+	if days := describeSpecDays(s); days != "" {
+		desc += " " + days
+	}
+	if s.Location != nil && s.Location != time.Local {
+		desc += " (" + s.Location.String() + ")"
+	}
+	return desc
+}
+
+func describeSpecTime(s *cron.SpecSchedule) string {
+	second, secondOK := specValue(s.Second, 0, 59)
+	minute, minuteOK := specValue(s.Minute, 0, 59)
+	hour, hourOK := specValue(s.Hour, 0, 23)
+	switch {
+	case secondOK && minuteOK && hourOK && second == 0:
+		return fmt.Sprintf("at %02d:%02d", hour, minute)
+	case secondOK && minuteOK && hourOK:
+		return fmt.Sprintf("at %02d:%02d:%02d", hour, minute, second)
+	}
+
+	allSeconds := specAll(s.Second, 0, 59)
+	allMinutes := specAll(s.Minute, 0, 59)
+	allHours := specAll(s.Hour, 0, 23)
+	switch {
+	case allSeconds && allMinutes && allHours:
+		return "every second"
+	case secondOK && second == 0 && allMinutes && allHours:
+		return "every minute"
+	}
+
+	var parts []string
+	if !secondOK || second != 0 {
+		parts = append(parts, specField(s.Second, 0, 59, "second", nil))
+	}
+	parts = append(parts, specField(s.Minute, 0, 59, "minute", nil))
+	if !allHours || !allMinutes {
+		parts = append(parts, specField(s.Hour, 0, 23, "hour", nil))
+	}
+	return "at " + strings.Join(parts, " of ")
+}
+
+func describeSpecDays(s *cron.SpecSchedule) string {
+	var parts []string
+	allDom := specAll(s.Dom, 1, 31)
+	allDow := specAll(s.Dow, 0, 6)
+	switch {
+	case !allDom && !allDow:
+		// Either of them matches, like cron does.
+		parts = append(parts, "on day "+specList(s.Dom, 1, 31, nil)+" of the month or on "+specList(s.Dow, 0, 6, weekdayNames))
+	case !allDom:
+		parts = append(parts, "on day "+specList(s.Dom, 1, 31, nil)+" of the month")
+	case !allDow:
+		parts = append(parts, "on "+specList(s.Dow, 0, 6, weekdayNames))
+	}
+	if !specAll(s.Month, 1, 12) {
+		parts = append(parts, "in "+specList(s.Month, 1, 12, monthNames))
+	}
+	return strings.Join(parts, " ")
+}
+
+// specValue returns the only value of the field, false if the field has none or more than one.
+func specValue(bits uint64, minValue, maxValue int) (int, bool) {
+	value, found := 0, false
+	for v := minValue; v <= maxValue; v++ {
+		if bits&(1<<uint(v)) == 0 {
+			continue
+		}
+		if found {
+			return 0, false
+		}
+		value, found = v, true
+	}
+	return value, found
+}
+
+// specAll determines if the field matches every value.
+func specAll(bits uint64, minValue, maxValue int) bool {
+	for v := minValue; v <= maxValue; v++ {
+		if bits&(1<<uint(v)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// specField describes the field as "every <unit>" or "<unit> <values>".
+func specField(bits uint64, minValue, maxValue int, unit string, names []string) string {
+	if specAll(bits, minValue, maxValue) {
+		return "every " + unit
+	}
+	return unit + " " + specList(bits, minValue, maxValue, names)
+}
+
+// specList lists the values of the field, collapsing three or more consecutive values into a range.
+func specList(bits uint64, minValue, maxValue int, names []string) string {
+	name := func(v int) string {
+		if names != nil {
+			return names[v]
+		}
+		return strconv.Itoa(v)
+	}
+
+	var items []string
+	for v := minValue; v <= maxValue; v++ {
+		if bits&(1<<uint(v)) == 0 {
+			continue
+		}
+		end := v
+		for end+1 <= maxValue && bits&(1<<uint(end+1)) != 0 {
+			end++
+		}
+		switch {
+		case end-v >= 2:
+			items = append(items, name(v)+"-"+name(end))
+		case end > v:
+			items = append(items, name(v), name(end))
+		default:
+			items = append(items, name(v))
+		}
+		v = end
+	}
+	return strings.Join(items, ",")
+}
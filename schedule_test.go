@@ -0,0 +1,268 @@
+package cronx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnceSchedule_Next(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	s := NewOnceSchedule(at)
+
+	assert.Equal(t, at, s.Next(at.Add(-time.Minute)))
+	assert.True(t, s.Next(at).IsZero())
+	assert.True(t, s.Next(at.Add(time.Minute)).IsZero())
+	assert.Equal(t, "once at 2024-01-01 09:00:00", s.String())
+}
+
+func TestFixedDelaySchedule_Next(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	s := NewFixedDelaySchedule(5 * time.Minute)
+
+	assert.Equal(t, now.Add(5*time.Minute), s.Next(now))
+
+	// The run starts, the schedule fires again at the earliest possible time of the next run.
+	start := now.Add(5 * time.Minute)
+	assert.True(t, s.due(start))
+	assert.Equal(t, start.Add(5*time.Minute), s.Next(start))
+
+	// The run is still running, so the fire only reschedules.
+	fire := start.Add(5 * time.Minute)
+	assert.False(t, s.due(fire))
+	assert.Equal(t, fire.Add(5*time.Minute), s.Next(fire))
+
+	// The run finishes, the next run counts from the finish.
+	finish := fire.Add(2 * time.Minute)
+	s.finish(finish)
+	fire = fire.Add(5 * time.Minute)
+	assert.False(t, s.due(fire))
+	assert.Equal(t, finish.Add(5*time.Minute), s.Next(fire))
+	assert.True(t, s.due(finish.Add(5*time.Minute)))
+	assert.Equal(t, "5m0s after the previous run", s.String())
+}
+
+func TestCalendar_IsBusinessDay(t *testing.T) {
+	t.Parallel()
+
+	holiday := time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)
+	c := NewCalendar(holiday)
+
+	tests := []struct {
+		name string
+		day  time.Time
+		want bool
+	}{
+		{
+			name: "Weekday",
+			day:  time.Date(2024, 12, 24, 10, 0, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "Holiday",
+			day:  time.Date(2024, 12, 25, 10, 0, 0, 0, time.UTC),
+			want: false,
+		},
+		{
+			name: "Saturday",
+			day:  time.Date(2024, 12, 28, 10, 0, 0, 0, time.UTC),
+			want: false,
+		},
+		{
+			name: "Sunday",
+			day:  time.Date(2024, 12, 29, 10, 0, 0, 0, time.UTC),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, c.IsBusinessDay(tt.day))
+		})
+	}
+}
+
+func TestBusinessDaySchedule_Next(t *testing.T) {
+	t.Parallel()
+
+	daily, err := DefaultParser.Parse("0 0 9 * * *")
+	require.NoError(t, err)
+	s := NewBusinessDaySchedule(daily, NewCalendar(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)))
+
+	// Tuesday to Thursday, skipping the holiday.
+	got := s.Next(time.Date(2024, 12, 24, 10, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 12, 26, 9, 0, 0, 0, time.UTC), got)
+
+	// Friday to Monday, skipping the weekend.
+	got = s.Next(time.Date(2024, 12, 27, 10, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 12, 30, 9, 0, 0, 0, time.UTC), got)
+
+	assert.Equal(t, "at 09:00 on business days", s.String())
+}
+
+func TestLastBusinessDaySchedule_Next(t *testing.T) {
+	t.Parallel()
+
+	// August 31st 2024 is a Saturday, and August 30th is a holiday.
+	s := NewLastBusinessDaySchedule(17, 30, NewCalendar(time.Date(2024, 8, 30, 0, 0, 0, 0, time.UTC)))
+
+	tests := []struct {
+		name string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "Skip weekend and holiday",
+			from: time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 8, 29, 17, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "Next month after the run",
+			from: time.Date(2024, 8, 29, 17, 30, 0, 0, time.UTC),
+			want: time.Date(2024, 9, 30, 17, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "Across the year",
+			from: time.Date(2024, 12, 31, 18, 0, 0, 0, time.UTC),
+			want: time.Date(2025, 1, 31, 17, 30, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, s.Next(tt.from))
+		})
+	}
+	assert.Equal(t, "last business day of the month at 17:30", s.String())
+}
+
+func TestManager_ScheduleWith(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(WithAutoStartDisabled())
+	m.Start()
+	defer m.Stop()
+
+	noop := func(context.Context) error { return nil }
+	require.NoError(t, m.ScheduleWithFunc(NewOnceSchedule(time.Now().Add(time.Hour)), "once", noop))
+	require.NoError(t, m.ScheduleWithFunc(NewFixedDelaySchedule(time.Hour), "delay", noop))
+	require.NoError(t, m.ScheduleWithFunc(cron.Every(time.Hour), "every", noop))
+	require.Error(t, m.ScheduleWithFunc(nil, "invalid", noop))
+
+	jobs := map[string]*Job{}
+	for _, v := range m.GetEntries() {
+		j := v.Job.(*Job)
+		jobs[j.Name] = j
+	}
+	require.Len(t, jobs, 3)
+	assert.Equal(t, "1h0m0s after the previous run", jobs["delay"].Schedule)
+	assert.Equal(t, "@every 1h0m0s", jobs["every"].Schedule)
+	require.Len(t, m.downJobs, 1)
+	assert.Equal(t, StatusCodeDown, m.downJobs[0].Status)
+
+	// A once job is completed after a successful run.
+	jobs["once"].Run()
+	assert.Equal(t, StatusCodeCompleted, jobs["once"].Status)
+
+	// A fixed delay job keeps its entry, and counts the next run from the finish of the run.
+	delay := jobs["delay"]
+	id := delay.EntryID
+	delay.Run()
+	assert.Equal(t, StatusCodeSuccess, delay.Status)
+	assert.Equal(t, id, delay.EntryID)
+	assert.Len(t, m.GetEntries(), 3)
+	next := delay.schedule.Next(time.Now())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), next, time.Minute)
+
+	// A fire before the delay has passed does not run the job.
+	delay.Status = StatusCodeUp
+	delay.Run()
+	assert.Equal(t, StatusCodeUp, delay.Status)
+}
+
+func TestDescribeSchedule(t *testing.T) {
+	t.Parallel()
+
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{
+			name: "Daily",
+			spec: "0 0 9 * * *",
+			want: "at 09:00",
+		},
+		{
+			name: "Seconds",
+			spec: "30 15 17 * * *",
+			want: "at 17:15:30",
+		},
+		{
+			name: "Weekdays",
+			spec: "0 30 9 * * 1-5",
+			want: "at 09:30 on Mon-Fri",
+		},
+		{
+			name: "Every second",
+			spec: "* * * * * *",
+			want: "every second",
+		},
+		{
+			name: "Every minute",
+			spec: "0 * * * * *",
+			want: "every minute",
+		},
+		{
+			name: "Step",
+			spec: "0 */15 * * * *",
+			want: "at minute 0,15,30,45 of every hour",
+		},
+		{
+			name: "Hour range",
+			spec: "0 30 9-17 * * *",
+			want: "at minute 30 of hour 9-17",
+		},
+		{
+			name: "Second of every minute",
+			spec: "30 * * * * *",
+			want: "at second 30 of every minute",
+		},
+		{
+			name: "Day of month and month",
+			spec: "0 0 0 1,15 1,7 *",
+			want: "at 00:00 on day 1,15 of the month in Jan,Jul",
+		},
+		{
+			name: "Day of month or weekday",
+			spec: "0 0 8 1 * SUN",
+			want: "at 08:00 on day 1 of the month or on Sun",
+		},
+		{
+			name: "Location",
+			spec: "CRON_TZ=Asia/Jakarta 0 0 9 * * *",
+			want: "at 09:00 (" + jakarta.String() + ")",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			schedule, err := DefaultParser.Parse(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, describeSchedule(schedule))
+		})
+	}
+	assert.Equal(t, "@every 1h0m0s", describeSchedule(cron.Every(time.Hour)))
+}
@@ -1,45 +1,149 @@
 package cronx
 
 import (
+	"fmt"
+	"html/template"
+	"io/fs"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/rizalgowandy/cronx/page"
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
 	gdkMiddleware "github.com/rizalgowandy/gdk/pkg/httpx/echo/middleware"
+	"github.com/rizalgowandy/gdk/pkg/jsonx"
+	"github.com/robfig/cron/v3"
 )
 
 const (
 	QueryParamSort = "sort"
 )
 
+// MetricsContentType is the content type of the Prometheus text format.
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// EventsHeartbeatInterval defines how often a comment is sent to keep the event stream open.
+var EventsHeartbeatInterval = 15 * time.Second
+
+// StaticCacheControl is the cache header of the embedded assets.
+const StaticCacheControl = "public, max-age=31536000, immutable"
+
 // SleepDuration defines the duration to sleep the server if the defined address is busy.
 const SleepDuration = time.Second * 10
 
-// NewServer creates a new HTTP server.
+// ServerOption configures the HTTP handler of the server.
+type ServerOption func(*serverConfig)
+
+// serverConfig describes the HTTP handler of the server.
+type serverConfig struct {
+	// prefix is the path where the handler is mounted, e.g. /admin/cron.
+	prefix string
+	// authenticators authenticate every request, the first one returning a role wins.
+	// Empty means every request is allowed as an operator.
+	authenticators []AuthenticatorItf
+	// corsOrigins are the origins allowed to call the server from a browser.
+	// Empty means cross-origin requests are not allowed.
+	corsOrigins []string
+	// templateFuncs are the functions available to the overridden templates.
+	templateFuncs template.FuncMap
+	// templateOverrides override or extend the named templates of the pages.
+	templateOverrides []page.Override
+}
+
+// WithPrefix mounts every route of the handler under the path prefix, e.g. /admin/cron.
+// The handler expects the full request path, so the prefix must not be stripped by the router.
+func WithPrefix(prefix string) ServerOption {
+	return func(c *serverConfig) {
+		c.prefix = "/" + strings.Trim(prefix, "/")
+		if c.prefix == "/" {
+			c.prefix = ""
+		}
+	}
+}
+
+// WithAuth requires every request to be authenticated by one of the authenticators.
+// Viewing is allowed for every role, while controlling the jobs requires RoleOperator.
+func WithAuth(authenticators ...AuthenticatorItf) ServerOption {
+	return func(c *serverConfig) {
+		c.authenticators = append(c.authenticators, authenticators...)
+	}
+}
+
+// WithCORS allows the origins to call the server from a browser, e.g. https://admin.example.com.
+// Use "*" to allow any origin.
+func WithCORS(origins ...string) ServerOption {
+	return func(c *serverConfig) {
+		c.corsOrigins = append(c.corsOrigins, origins...)
+	}
+}
+
+// WithTemplateFS overrides or extends the named templates of the pages
+// with the files of fsys matching the patterns, e.g. WithTemplateFS(templates, "*.gohtml").
+// See page.Override for the list of named templates.
+func WithTemplateFS(fsys fs.FS, patterns ...string) ServerOption {
+	return func(c *serverConfig) {
+		c.templateOverrides = append(c.templateOverrides, page.OverrideFS(fsys, patterns...))
+	}
+}
+
+// WithTemplate overrides or extends the named templates of the pages
+// with every template associated with tmpl.
+// See page.Override for the list of named templates.
+func WithTemplate(tmpl *template.Template) ServerOption {
+	return func(c *serverConfig) {
+		c.templateOverrides = append(c.templateOverrides, page.OverrideTemplate(tmpl))
+	}
+}
+
+// WithTemplateFuncs adds the functions available to the overridden templates.
+func WithTemplateFuncs(funcs template.FuncMap) ServerOption {
+	return func(c *serverConfig) {
+		if c.templateFuncs == nil {
+			c.templateFuncs = template.FuncMap{}
+		}
+		for k, v := range funcs {
+			c.templateFuncs[k] = v
+		}
+	}
+}
+
+// NewHandler creates a handler of the dashboard and API, mountable in any router.
 // - /			=> current server status.
 // - /jobs		=> current jobs as frontend html.
+// - /histories	=> run histories as frontend html.
 // - /api/jobs	=> current jobs as json.
-func NewServer(manager *Manager, address string) (*http.Server, error) {
-	// Create server.
-	e := echo.New()
-	e.HideBanner = true
-	e.HidePort = true
-	e.Use(middleware.CORS())
-	e.Use(middleware.Recover())
-	e.Use(middleware.RemoveTrailingSlash())
-	e.Use(gdkMiddleware.RequestID())
-
-	// Create server controller.
-	ctrl := &ServerController{Manager: manager}
+// - /api/histories	=> run histories as json.
+// - /api/events	=> run events as Server-Sent Events.
+// - /api/openapi.json	=> OpenAPI document of every route.
+// - /api/jobs/:id/breaker/reset	=> close the circuit breaker of a job, requires RoleOperator.
+// - /api/jobs/:id/cancel	=> cancel the current run of a job, requires RoleOperator.
+// - /metrics	=> current metrics in Prometheus text format.
+// - /static/:version/*	=> the embedded assets of the pages, cached forever.
+// - /healthz	=> liveness check as json, 503 if unhealthy, see Manager.Liveness.
+// - /readyz	=> readiness check as json, 503 if unhealthy, see Manager.Readiness.
+//
+// Example with net/http:
+//
+//	mux.Handle("/admin/cron/", cronx.NewHandler(manager, cronx.WithPrefix("/admin/cron")))
+func NewHandler(manager *Manager, opts ...ServerOption) http.Handler {
+	e, _ := newEcho(manager, opts...)
+	return e
+}
 
-	// Register routes.
-	e.GET("/", ctrl.HealthCheck)
-	e.GET("/jobs", ctrl.Jobs)
-	e.GET("/histories", ctrl.Histories)
-	e.GET("/api/jobs", ctrl.APIJobs)
-	e.GET("/api/histories", ctrl.APIHistories)
+// NewServer creates a new HTTP server.
+// See NewHandler for the list of routes.
+// Returns an error if the overridden templates cannot be parsed.
+func NewServer(manager *Manager, address string, opts ...ServerOption) (*http.Server, error) {
+	e, ctrl := newEcho(manager, opts...)
+	if _, err := ctrl.Templates.Jobs(); err != nil {
+		return nil, errorx.E(err)
+	}
+	if _, err := ctrl.Templates.Histories(); err != nil {
+		return nil, errorx.E(err)
+	}
 
 	return &http.Server{
 		Addr:              address,
@@ -50,44 +154,88 @@ func NewServer(manager *Manager, address string) (*http.Server, error) {
 
 // NewSideCarServer creates a new sidecar HTTP server.
 // HTTP server will be start automatically.
-// - /			=> current server status.
-// - /jobs		=> current jobs as frontend html.
-// - /api/jobs	=> current jobs as json.
-func NewSideCarServer(manager *Manager, address string) {
+// See NewHandler for the list of routes.
+func NewSideCarServer(manager *Manager, address string, opts ...ServerOption) {
+	e, _ := newEcho(manager, opts...)
+
+	// Overcome issue with socket-master respawning 2nd app,
+	// We will keep trying to run the server.
+	// If the current address is busy,
+	// sleep then try again until the address has become available.
+	for {
+		if err := e.Start(address); err != nil {
+			time.Sleep(SleepDuration)
+		}
+	}
+}
+
+// newEcho creates the router of the server and its controller.
+func newEcho(manager *Manager, opts ...ServerOption) (*echo.Echo, *ServerController) {
+	cfg := &serverConfig{
+		prefix:            "",
+		authenticators:    nil,
+		corsOrigins:       nil,
+		templateFuncs:     nil,
+		templateOverrides: nil,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	// Create server.
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
-	e.Use(middleware.CORS())
+	if len(cfg.corsOrigins) > 0 {
+		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+			AllowOrigins: cfg.corsOrigins,
+			AllowHeaders: []string{echo.HeaderAuthorization, echo.HeaderContentType},
+		}))
+	}
 	e.Use(middleware.Recover())
 	e.Use(middleware.RemoveTrailingSlash())
 	e.Use(gdkMiddleware.RequestID())
 
 	// Create server controller.
-	ctrl := &ServerController{Manager: manager}
+	ctrl := &ServerController{
+		Manager:   manager,
+		Prefix:    cfg.prefix,
+		Templates: page.NewTemplates(cfg.templateFuncs, cfg.templateOverrides...),
+	}
+
+	// Register the assets, public since they contain nothing about the jobs.
+	e.GET(cfg.prefix+page.StaticPath+"/*", ctrl.Static)
+
+	// Register the probes, public since an orchestrator cannot authenticate.
+	e.GET(cfg.prefix+"/healthz", ctrl.Liveness)
+	e.GET(cfg.prefix+"/readyz", ctrl.Readiness)
 
 	// Register routes.
-	e.GET("/", ctrl.HealthCheck)
-	e.GET("/jobs", ctrl.Jobs)
-	e.GET("/histories", ctrl.Histories)
-	e.GET("/api/jobs", ctrl.APIJobs)
-	e.GET("/api/histories", ctrl.APIHistories)
+	g := e.Group(cfg.prefix, authenticate(cfg.authenticators))
+	operator := requireRole(RoleOperator)
+	g.GET("", ctrl.HealthCheck)
+	g.GET("/", ctrl.HealthCheck)
+	g.GET("/jobs", ctrl.Jobs)
+	g.GET("/histories", ctrl.Histories)
+	g.GET("/api/jobs", ctrl.APIJobs)
+	g.GET("/api/histories", ctrl.APIHistories)
+	g.GET("/api/events", ctrl.APIEvents)
+	g.GET("/api/openapi.json", ctrl.OpenAPI)
+	g.POST("/api/jobs/:id/breaker/reset", ctrl.APIResetBreaker, operator)
+	g.POST("/api/jobs/:id/cancel", ctrl.APICancel, operator)
+	g.GET("/metrics", ctrl.Metrics)
 
-	// Overcome issue with socket-master respawning 2nd app,
-	// We will keep trying to run the server.
-	// If the current address is busy,
-	// sleep then try again until the address has become available.
-	for {
-		if err := e.Start(address); err != nil {
-			time.Sleep(SleepDuration)
-		}
-	}
+	return e, ctrl
 }
 
 // ServerController is http server controller.
 type ServerController struct {
 	// Manager controls all the underlying job.
 	Manager *Manager
+	// Prefix is the path where the routes are mounted, empty if mounted at the root.
+	Prefix string
+	// Templates are the page templates, nil means the built-in templates.
+	Templates *page.Templates
 }
 
 // HealthCheck returns server status.
@@ -95,19 +243,37 @@ func (c *ServerController) HealthCheck(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, c.Manager.GetInfo())
 }
 
+// Liveness returns the liveness check of the manager, 503 if any check fails.
+func (c *ServerController) Liveness(ctx echo.Context) error {
+	return healthJSON(ctx, c.Manager.Liveness(ctx.Request().Context()))
+}
+
+// Readiness returns the readiness check of the manager, 503 if any check fails.
+func (c *ServerController) Readiness(ctx echo.Context) error {
+	return healthJSON(ctx, c.Manager.Readiness(ctx.Request().Context()))
+}
+
+// healthJSON writes the report with the status code matching the result.
+func healthJSON(ctx echo.Context, report HealthReport) error {
+	if !report.Healthy() {
+		return ctx.JSON(http.StatusServiceUnavailable, report)
+	}
+	return ctx.JSON(http.StatusOK, report)
+}
+
 // Jobs return job status as frontend template.
 func (c *ServerController) Jobs(ctx echo.Context) error {
-	index, err := page.GetJobsPageTemplate()
+	index, err := c.Templates.Jobs()
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, map[string]string{
 			"error": err.Error(),
 		})
 	}
 
-	return index.Execute(
-		ctx.Response().Writer,
-		c.Manager.GetStatusData(ctx.QueryParam(QueryParamSort)),
-	)
+	data := c.Manager.GetStatusData(ctx.QueryParam(QueryParamSort))
+	data.BasePath = c.Prefix
+
+	return index.Execute(ctx.Response().Writer, data)
 }
 
 // APIJobs returns job status as json.
@@ -118,9 +284,60 @@ func (c *ServerController) APIJobs(ctx echo.Context) error {
 	)
 }
 
+// APIResetBreaker closes the circuit breaker of a job, and returns job status as json.
+func (c *ServerController) APIResetBreaker(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if err := c.Manager.ResetBreaker(cron.EntryID(id)); err != nil {
+		return ctx.JSON(http.StatusNotFound, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		c.Manager.GetStatusData(ctx.QueryParam(QueryParamSort)),
+	)
+}
+
+// APICancel cancels the current run of a job.
+// The run is recorded as cancelled by the authenticated operator.
+func (c *ServerController) APICancel(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	job, ok := c.Manager.commander.Entry(cron.EntryID(id)).Job.(*Job)
+	if !ok {
+		return ctx.JSON(http.StatusNotFound, map[string]string{
+			"error": "job not found",
+		})
+	}
+
+	operator, _ := ctx.Get(contextKeyOperator).(string)
+	if !job.Cancel(operator) {
+		return ctx.JSON(http.StatusConflict, map[string]string{
+			"error": "job is not running",
+		})
+	}
+
+	// The run is recorded as cancelled once the job has stopped.
+	return ctx.JSON(http.StatusAccepted, map[string]string{
+		"cancelled_by": operator,
+	})
+}
+
 // Histories return job history as frontend template.
 func (c *ServerController) Histories(ctx echo.Context) error {
-	index, err := page.GetHistoryTemplate()
+	index, err := c.Templates.Histories()
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, map[string]string{
 			"error": err.Error(),
@@ -142,6 +359,7 @@ func (c *ServerController) Histories(ctx echo.Context) error {
 			"error": err.Error(),
 		})
 	}
+	data.BasePath = c.Prefix
 
 	return index.Execute(ctx.Response().Writer, data)
 }
@@ -166,3 +384,56 @@ func (c *ServerController) APIHistories(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, data)
 }
+
+// APIEvents streams the run events as Server-Sent Events until the client disconnects.
+func (c *ServerController) APIEvents(ctx echo.Context) error {
+	events, unsubscribe := c.Manager.Subscribe()
+	defer unsubscribe()
+
+	w := ctx.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	heartbeat := time.NewTicker(EventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request().Context().Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case event := <-events:
+			data, err := jsonx.Marshal(event)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return nil
+			}
+		}
+		w.Flush()
+	}
+}
+
+// Static serves the embedded assets of the pages.
+// The path contains the version of the assets, so they can be cached forever.
+func (c *ServerController) Static(ctx echo.Context) error {
+	assets := page.StaticFS()
+	if _, err := fs.Stat(assets, ctx.Param("*")); err == nil {
+		ctx.Response().Header().Set(echo.HeaderCacheControl, StaticCacheControl)
+	}
+	return echo.StaticDirectoryHandler(assets, false)(ctx)
+}
+
+// Metrics returns job metrics in the Prometheus text format.
+func (c *ServerController) Metrics(ctx echo.Context) error {
+	ctx.Response().Header().Set(echo.HeaderContentType, MetricsContentType)
+	ctx.Response().WriteHeader(http.StatusOK)
+	return c.Manager.WriteMetrics(ctx.Response().Writer)
+}
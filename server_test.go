@@ -1,12 +1,24 @@
 package cronx
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"html/template"
+	"io/fs"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/rizalgowandy/cronx/page"
+	"github.com/rizalgowandy/cronx/storage"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestServerController_APIJobs(t *testing.T) {
@@ -95,6 +107,8 @@ func TestServerController_Jobs(t *testing.T) {
 		fields  fields
 		expect  int
 		wantErr bool
+		// want are the parts of the page the live update of the rows relies on.
+		want []string
 	}{
 		{
 			name:   "Success",
@@ -105,6 +119,65 @@ func TestServerController_Jobs(t *testing.T) {
 			expect:  http.StatusOK,
 			wantErr: false,
 		},
+		{
+			name:   "Success with result",
+			target: "/jobs",
+			fields: fields{
+				Manager: func() *Manager {
+					manager := NewManager(WithAutoStartDisabled())
+					_ = manager.Schedule("@every 5m", &invoiceJob{})
+					job := manager.GetEntries()[0].Job.(*Job)
+					job.UpdateResult(storage.Result{Counters: map[string]int64{"processed": 1}})
+					job.UpdateResult(storage.Result{Counters: map[string]int64{"processed": 3}})
+					return manager
+				}(),
+			},
+			expect:  http.StatusOK,
+			wantErr: false,
+			want: []string{
+				`<tr data-entry-id="1"`,
+				`<span data-status>`,
+				`<span data-cancel hidden>`,
+				`<span data-error hidden>`,
+				`<span data-latency>`,
+				`<span data-prev-run>`,
+				`<span data-next-run>`,
+			},
+		},
+		{
+			name:   "Success with open breaker",
+			target: "/jobs",
+			fields: fields{
+				Manager: func() *Manager {
+					manager := NewManager(WithAutoStartDisabled(), WithCircuitBreaker(1, time.Hour))
+					_ = manager.Schedule("@every 5m", &invoiceJob{})
+					job := manager.GetEntries()[0].Job.(*Job)
+					job.ConsecutiveFailures = 1
+					job.updateBreaker(errors.New("error"), time.Now())
+					return manager
+				}(),
+			},
+			expect:  http.StatusOK,
+			wantErr: false,
+			want:    []string{`<span data-breaker>`},
+		},
+		{
+			name:   "Success with overdue",
+			target: "/jobs",
+			fields: fields{
+				Manager: func() *Manager {
+					manager := NewManager(WithAutoStartDisabled(), WithSuccessWindow("invoiceJob", time.Hour))
+					_ = manager.Schedule("@every 5m", &invoiceJob{})
+					job := manager.GetEntries()[0].Job.(*Job)
+					job.observeSuccess(time.Now().Add(-2 * time.Hour))
+					job.UpdateOverdue(time.Now())
+					return manager
+				}(),
+			},
+			expect:  http.StatusOK,
+			wantErr: false,
+			want:    []string{`data-overdue class="error"`},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -118,7 +191,242 @@ func TestServerController_Jobs(t *testing.T) {
 			}
 			if assert.NoError(t, ctrl.Jobs(c)) {
 				assert.Equal(t, tt.expect, rec.Code)
+				for _, v := range tt.want {
+					assert.Contains(t, rec.Body.String(), v)
+				}
+			}
+		})
+	}
+}
+
+func TestServerController_Metrics(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	ctrl := &ServerController{
+		Manager: NewManager(),
+	}
+	if assert.NoError(t, ctrl.Metrics(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, MetricsContentType, rec.Header().Get(echo.HeaderContentType))
+		assert.Contains(t, rec.Body.String(), MetricJobsDown)
+	}
+}
+
+func TestServerController_APIResetBreaker(t *testing.T) {
+	manager := NewManager(WithAutoStartDisabled())
+	_ = manager.Schedule("@every 5m", &invoiceJob{})
+	id := strconv.Itoa(int(manager.GetEntries()[0].ID))
+
+	tests := []struct {
+		name   string
+		id     string
+		expect int
+	}{
+		{
+			name:   "Success",
+			id:     id,
+			expect: http.StatusOK,
+		},
+		{
+			name:   "Invalid id",
+			id:     "abc",
+			expect: http.StatusBadRequest,
+		},
+		{
+			name:   "Not found",
+			id:     "100",
+			expect: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/api/jobs/"+tt.id+"/breaker/reset", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+
+			ctrl := &ServerController{
+				Manager: manager,
+			}
+			if assert.NoError(t, ctrl.APIResetBreaker(c)) {
+				assert.Equal(t, tt.expect, rec.Code)
 			}
 		})
 	}
 }
+
+type historiesStub struct {
+	storageStub
+}
+
+func (h historiesStub) ReadHistories(context.Context, *storage.HistoryFilter) ([]storage.History, error) {
+	return []storage.History{{ID: 1, Name: "payBill", Status: StatusCodeSuccess.String()}}, nil
+}
+
+func TestNewHandler(t *testing.T) {
+	manager := NewManager(WithAutoStartDisabled(), WithStorage(historiesStub{}))
+	_ = manager.Schedule("@every 5m", &invoiceJob{})
+
+	mux := http.NewServeMux()
+	mux.Handle("/admin/cron/", NewHandler(manager, WithPrefix("/admin/cron/")))
+
+	tests := []struct {
+		name     string
+		target   string
+		expect   int
+		contains string
+	}{
+		{
+			name:   "Health check",
+			target: "/admin/cron/",
+			expect: http.StatusOK,
+		},
+		{
+			name:     "Jobs",
+			target:   "/admin/cron/jobs",
+			expect:   http.StatusOK,
+			contains: `href="/admin/cron/histories"`,
+		},
+		{
+			name:     "Histories",
+			target:   "/admin/cron/histories",
+			expect:   http.StatusOK,
+			contains: `href="/admin/cron/jobs"`,
+		},
+		{
+			name:     "API histories",
+			target:   "/admin/cron/api/histories?limit=1",
+			expect:   http.StatusOK,
+			contains: `"next_uri":"/admin/cron/api/histories?`,
+		},
+		{
+			name:   "API jobs",
+			target: "/admin/cron/api/jobs",
+			expect: http.StatusOK,
+		},
+		{
+			name:   "Outside the prefix",
+			target: "/jobs",
+			expect: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expect, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.contains)
+		})
+	}
+}
+
+func TestServerController_APIEvents(t *testing.T) {
+	manager := NewManager(WithAutoStartDisabled())
+	_ = manager.ScheduleFunc("@every 5m", "payBill", func(context.Context) error { return nil })
+
+	srv := httptest.NewServer(NewHandler(manager))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/events", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	manager.GetEntries()[0].Job.(*Job).Run()
+
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if scanner.Text() == "event: "+EventRunFinished.String() {
+			break
+		}
+	}
+	assert.Contains(t, lines, "event: "+EventRunStarted.String())
+	assert.Contains(t, lines, "event: "+EventRunFinished.String())
+}
+
+func TestServerController_Static(t *testing.T) {
+	handler := NewHandler(
+		NewManager(WithAutoStartDisabled()),
+		WithPrefix("/admin/cron"),
+		WithAuth(NewTokenAuth(map[string]Role{"token": RoleViewer})),
+	)
+
+	// Every embedded asset is served with a long-lived cache.
+	assets := page.StaticFS()
+	err := fs.WalkDir(assets, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		want, err := fs.ReadFile(assets, path)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/cron"+page.StaticPath+"/"+path, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, StaticCacheControl, rec.Header().Get("Cache-Control"), path)
+		assert.Equal(t, want, rec.Body.Bytes(), path)
+		return nil
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/cron"+page.StaticPath+"/unknown.js", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Empty(t, rec.Header().Get("Cache-Control"))
+}
+
+func TestNewHandlerWithTemplates(t *testing.T) {
+	manager := NewManager(WithAutoStartDisabled())
+	_ = manager.Schedule("@every 5m", &invoiceJob{})
+
+	handler := NewHandler(
+		manager,
+		WithTemplateFS(fstest.MapFS{
+			"columns.gohtml": &fstest.MapFile{
+				Data: []byte(`{{define "job_columns"}}<th>Owner</th>{{end}}` +
+					`{{define "job_cells"}}<td>{{owner .Job.Name}}</td>{{end}}`),
+			},
+		}, "*.gohtml"),
+		WithTemplate(template.Must(template.New("brand").Parse(
+			`{{define "brand"}}Acme{{end}}{{define "title"}}Acme Cron{{end}}`,
+		))),
+		WithTemplateFuncs(template.FuncMap{"owner": func(name string) string { return name + "@acme" }}),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, strings.Count(rec.Body.String(), "<title>"))
+	assert.Contains(t, rec.Body.String(), "<title>Acme Cron</title>")
+	assert.Contains(t, rec.Body.String(), "<th>Owner</th>")
+	assert.Contains(t, rec.Body.String(), "<td>invoiceJob@acme</td>")
+	assert.Contains(t, rec.Body.String(), "Acme")
+	assert.NotContains(t, rec.Body.String(), "stopwatch icon")
+}
+
+func TestNewServerWithInvalidTemplates(t *testing.T) {
+	_, err := NewServer(
+		NewManager(WithAutoStartDisabled()),
+		":0",
+		WithTemplateFS(fstest.MapFS{
+			"brand.gohtml": &fstest.MapFile{Data: []byte(`{{define "brand"}}{{owner}}{{end}}`)},
+		}, "*.gohtml"),
+	)
+	assert.Error(t, err)
+}
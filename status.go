@@ -18,10 +18,32 @@ const (
 	StatusCodeDown StatusCode = "DOWN"
 	// StatusCodeError describes that last run has failed.
 	StatusCodeError StatusCode = "ERROR"
+	// StatusCodeAbandoned describes that the run never finished, e.g. the process crashed in the middle of the run.
+	// This status is only recorded in the histories.
+	StatusCodeAbandoned StatusCode = "ABANDONED"
+	// StatusCodeBroken describes that the runs are skipped, because the job has failed too many times in a row.
+	StatusCodeBroken StatusCode = "BROKEN"
+	// StatusCodeQueued describes that current run is waiting for a worker to be available.
+	StatusCodeQueued StatusCode = "QUEUED"
+	// StatusCodePanic describes that last run has panicked.
+	StatusCodePanic StatusCode = "PANIC"
+	// StatusCodeCompleted describes that the job has succeeded on its only run, and will never run again.
+	StatusCodeCompleted StatusCode = "COMPLETED"
+	// StatusCodeCancelled describes that last run has been cancelled by an operator.
+	StatusCodeCancelled StatusCode = "CANCELLED"
+	// StatusCodeSkipped describes that last run has been skipped by an interceptor, e.g. interceptor.RateLimit.
+	StatusCodeSkipped StatusCode = "SKIPPED"
 
-	statusDown    uint32 = 0
-	statusUp      uint32 = 1
-	statusSuccess uint32 = 2
-	statusRunning uint32 = 3
-	statusError   uint32 = 4
+	statusDown      uint32 = 0
+	statusUp        uint32 = 1
+	statusSuccess   uint32 = 2
+	statusRunning   uint32 = 3
+	statusError     uint32 = 4
+	statusAbandoned uint32 = 5
+	statusBroken    uint32 = 6
+	statusQueued    uint32 = 7
+	statusPanic     uint32 = 8
+	statusCompleted uint32 = 9
+	statusCancelled uint32 = 10
+	statusSkipped   uint32 = 11
 )
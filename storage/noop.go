@@ -15,6 +15,18 @@ func (n NoopClient) WriteHistory(_ context.Context, _ *History) error {
 	return nil
 }
 
+func (n NoopClient) UpdateHistory(_ context.Context, _ *History) error {
+	return nil
+}
+
+func (n NoopClient) AbandonHistories(_ context.Context, _ *AbandonFilter) (int64, error) {
+	return 0, nil
+}
+
 func (n NoopClient) ReadHistories(_ context.Context, _ *HistoryFilter) ([]History, error) {
 	return nil, nil
 }
+
+func (n NoopClient) Ping(_ context.Context) error {
+	return nil
+}
@@ -42,7 +42,11 @@ func (p *PostgreClient) WriteHistory(ctx context.Context, req *History) error {
 			latency,
 			latency_text,
 			error,
-			metadata
+			metadata,
+			logs,
+			result,
+			queue_wait,
+			queue_wait_text
 		)
 		VALUES (
 		   $1,
@@ -54,12 +58,17 @@ func (p *PostgreClient) WriteHistory(ctx context.Context, req *History) error {
 		   $7,
 		   $8,
 		   $9,
-		   $10
+		   $10,
+		   $11,
+		   $12,
+		   $13,
+		   $14
 		)
+		RETURNING id
 		;
 	`
 
-	_, err = pool.Exec(
+	err = pool.QueryRow(
 		ctx,
 		query,
 		req.CreatedAt,
@@ -72,7 +81,11 @@ func (p *PostgreClient) WriteHistory(ctx context.Context, req *History) error {
 		req.LatencyText,
 		req.Error,
 		req.Metadata,
-	)
+		req.Logs,
+		req.Result,
+		req.QueueWait,
+		req.QueueWaitText,
+	).Scan(&req.ID)
 	if err != nil {
 		return errorx.E(err, fields)
 	}
@@ -107,6 +120,92 @@ func (p *PostgreClient) WriteHistory(ctx context.Context, req *History) error {
 	return nil
 }
 
+func (p *PostgreClient) UpdateHistory(ctx context.Context, req *History) error {
+	fields := errorx.Fields{tags.Request: req}
+
+	pool, err := p.db.GetWriter(ctx)
+	if err != nil {
+		return errorx.E(err, fields)
+	}
+
+	query := `
+		UPDATE cronx_histories
+		SET
+			status = $2,
+			status_code = $3,
+			finished_at = $4,
+			latency = $5,
+			latency_text = $6,
+			error = $7,
+			metadata = $8,
+			logs = $9,
+			result = $10,
+			queue_wait = $11,
+			queue_wait_text = $12
+		WHERE id = $1
+		;
+	`
+
+	_, err = pool.Exec(
+		ctx,
+		query,
+		req.ID,
+		req.Status,
+		req.StatusCode,
+		req.FinishedAt,
+		req.Latency,
+		req.LatencyText,
+		req.Error,
+		req.Metadata,
+		req.Logs,
+		req.Result,
+		req.QueueWait,
+		req.QueueWaitText,
+	)
+	if err != nil {
+		return errorx.E(err, fields)
+	}
+
+	return nil
+}
+
+func (p *PostgreClient) AbandonHistories(ctx context.Context, req *AbandonFilter) (int64, error) {
+	fields := errorx.Fields{tags.Request: req}
+
+	pool, err := p.db.GetWriter(ctx)
+	if err != nil {
+		return 0, errorx.E(err, fields)
+	}
+
+	query := `
+		UPDATE cronx_histories
+		SET
+			status = $4,
+			status_code = $5,
+			finished_at = $6
+		WHERE status = $1
+			AND metadata ->> 'machine_id' = $2
+			AND started_at < $3
+		;
+	`
+
+	tag, err := pool.Exec(
+		ctx,
+		query,
+		req.RunningStatus,
+		req.MachineID,
+		req.StartedBefore,
+		req.Status,
+		req.StatusCode,
+		req.FinishedAt,
+	)
+	if err != nil {
+		return 0, errorx.E(err, fields)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
 func (p *PostgreClient) ReadHistories(ctx context.Context, req *HistoryFilter) ([]History, error) {
 	fields := errorx.Fields{tags.Request: req}
 
@@ -128,11 +227,24 @@ func (p *PostgreClient) ReadHistories(ctx context.Context, req *HistoryFilter) (
 			"latency_text",
 			"error",
 			"metadata",
+			"logs",
+			"result",
+			"queue_wait",
+			"queue_wait_text",
 		).
 		From("cronx_histories").
 		Limit(uint64(req.Limit)).
 		PlaceholderFormat(squirrel.Dollar)
 
+	if req.Name != "" {
+		sq = sq.Where("name = ?", req.Name)
+	}
+	if req.Status != "" {
+		sq = sq.Where("status = ?", req.Status)
+	}
+	if req.Wave > 0 {
+		sq = sq.Where("(metadata ->> 'wave')::BIGINT = ?", req.Wave)
+	}
 	if req.StartingAfter != nil {
 		if !req.Sorts.Desc() {
 			sq = sq.Where("id > ?", *req.StartingAfter)
@@ -183,6 +295,10 @@ func (p *PostgreClient) ReadHistories(ctx context.Context, req *HistoryFilter) (
 			&cur.LatencyText,
 			&cur.Error,
 			&cur.Metadata,
+			&cur.Logs,
+			&cur.Result,
+			&cur.QueueWait,
+			&cur.QueueWaitText,
 		); err != nil {
 			return nil, errorx.E(err, fields)
 		}
@@ -192,3 +308,16 @@ func (p *PostgreClient) ReadHistories(ctx context.Context, req *HistoryFilter) (
 
 	return data, nil
 }
+
+func (p *PostgreClient) Ping(ctx context.Context) error {
+	pool, err := p.db.GetWriter(ctx)
+	if err != nil {
+		return errorx.E(err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		return errorx.E(err)
+	}
+
+	return nil
+}
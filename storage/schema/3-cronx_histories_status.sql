@@ -0,0 +1,3 @@
+CREATE INDEX cronx_histories_running_index
+	ON cronx_histories(started_at)
+	WHERE status = 'RUNNING';
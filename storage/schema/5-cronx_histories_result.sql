@@ -0,0 +1,2 @@
+ALTER TABLE cronx_histories
+	ADD COLUMN result JSONB DEFAULT '{}' NOT NULL;
@@ -0,0 +1,2 @@
+CREATE INDEX cronx_histories_name_status_index
+	ON cronx_histories(name, status, id DESC);
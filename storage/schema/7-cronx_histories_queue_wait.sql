@@ -0,0 +1,3 @@
+ALTER TABLE cronx_histories
+	ADD COLUMN queue_wait      INT8 DEFAULT 0  NOT NULL,
+	ADD COLUMN queue_wait_text TEXT DEFAULT '' NOT NULL;
@@ -8,30 +8,50 @@ import (
 	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
 	"github.com/rizalgowandy/gdk/pkg/jsonx"
 	"github.com/rizalgowandy/gdk/pkg/sortx"
+	"github.com/rizalgowandy/gdk/pkg/tags"
 )
 
 //go:generate gomodifytags -all --quiet -w -file storage.go -clear-tags
 //go:generate gomodifytags -all --quiet --skip-unexported -w -file storage.go -add-tags db,json
 //go:generate gomodifytags --quiet --skip-unexported -w -file storage.go -struct HistoryMetadata -add-tags json -add-options json=omitempty
 //go:generate gomodifytags --quiet --skip-unexported -w -file storage.go -struct ErrorDetail -add-tags json -add-options json=omitempty
+//go:generate gomodifytags --quiet --skip-unexported -w -file storage.go -struct HistoryLogs -add-tags json -add-options json=omitempty
+//go:generate gomodifytags --quiet --skip-unexported -w -file storage.go -struct LogLine -add-tags json -add-options json=omitempty
+//go:generate gomodifytags --quiet --skip-unexported -w -file storage.go -struct Result -add-tags json -add-options json=omitempty
 
 type Client interface {
+	// WriteHistory inserts a new history and fills the generated ID back to the request.
 	WriteHistory(ctx context.Context, req *History) error
+	// UpdateHistory replaces the outcome of a history previously inserted by WriteHistory.
+	UpdateHistory(ctx context.Context, req *History) error
+	// AbandonHistories marks in-flight histories that will never be finished.
+	// It returns the number of affected histories.
+	AbandonHistories(ctx context.Context, req *AbandonFilter) (int64, error)
 	ReadHistories(ctx context.Context, req *HistoryFilter) ([]History, error)
 }
 
+// PingerItf is implemented by the clients that can check whether the storage is reachable.
+// Clients without it are always considered reachable.
+type PingerItf interface {
+	Ping(ctx context.Context) error
+}
+
 type History struct {
-	ID          int64           `db:"id"           json:"id"`
-	CreatedAt   time.Time       `db:"created_at"   json:"created_at"`
-	Name        string          `db:"name"         json:"name"`
-	Status      string          `db:"status"       json:"status"`
-	StatusCode  int64           `db:"status_code"  json:"status_code"`
-	StartedAt   time.Time       `db:"started_at"   json:"started_at"`
-	FinishedAt  time.Time       `db:"finished_at"  json:"finished_at"`
-	Latency     int64           `db:"latency"      json:"latency"`
-	LatencyText string          `db:"latency_text" json:"latency_text"`
-	Error       ErrorDetail     `db:"error"        json:"error"`
-	Metadata    HistoryMetadata `db:"metadata"     json:"metadata"`
+	ID            int64           `db:"id"              json:"id"`
+	CreatedAt     time.Time       `db:"created_at"      json:"created_at"`
+	Name          string          `db:"name"            json:"name"`
+	Status        string          `db:"status"          json:"status"`
+	StatusCode    int64           `db:"status_code"     json:"status_code"`
+	StartedAt     time.Time       `db:"started_at"      json:"started_at"`
+	FinishedAt    time.Time       `db:"finished_at"     json:"finished_at"`
+	Latency       int64           `db:"latency"         json:"latency"`
+	LatencyText   string          `db:"latency_text"    json:"latency_text"`
+	Error         ErrorDetail     `db:"error"           json:"error"`
+	Metadata      HistoryMetadata `db:"metadata"        json:"metadata"`
+	Logs          HistoryLogs     `db:"logs"            json:"logs"`
+	Result        Result          `db:"result"          json:"result"`
+	QueueWait     int64           `db:"queue_wait"      json:"queue_wait"`
+	QueueWaitText string          `db:"queue_wait_text" json:"queue_wait_text"`
 }
 
 type HistoryMetadata struct {
@@ -40,6 +60,11 @@ type HistoryMetadata struct {
 	Wave       int64  `db:"wave"         json:"wave,omitempty"`
 	TotalWave  int64  `db:"total_wave"   json:"total_wave,omitempty"`
 	IsLastWave bool   `db:"is_last_wave" json:"is_last_wave,omitempty"`
+	// Offset is the splay and jitter in nanoseconds the run has been shifted from its schedule.
+	Offset     int64  `db:"offset"       json:"offset,omitempty"`
+	OffsetText string `db:"offset_text"  json:"offset_text,omitempty"`
+	// CancelledBy is the operator who has cancelled the run, empty if not cancelled.
+	CancelledBy string `db:"cancelled_by" json:"cancelled_by,omitempty"`
 }
 
 func (h *HistoryMetadata) Value() (driver.Value, error) {
@@ -63,6 +88,43 @@ type ErrorDetail struct {
 	Message      errorx.Message      `db:"message"       json:"message,omitempty"`
 	Line         errorx.Line         `db:"line"          json:"line,omitempty"`
 	MetricStatus errorx.MetricStatus `db:"metric_status" json:"metric_status,omitempty"`
+	Stack        []string            `db:"stack"         json:"stack,omitempty"`
+}
+
+// NewErrorDetail creates the error detail of the error.
+// The stack trace field of a recovered panic is moved to the Stack.
+func NewErrorDetail(err error) ErrorDetail {
+	if err == nil {
+		return ErrorDetail{}
+	}
+
+	e, ok := err.(*errorx.Error)
+	if !ok {
+		return ErrorDetail{Err: err.Error()}
+	}
+
+	detail := ErrorDetail{
+		Err:          e.Err.Error(),
+		Code:         e.Code,
+		Fields:       e.Fields,
+		OpTraces:     e.OpTraces,
+		Message:      e.Message,
+		Line:         e.Line,
+		MetricStatus: e.MetricStatus,
+		Stack:        nil,
+	}
+
+	if stack, ok := e.Fields[tags.StackTrace].([]string); ok {
+		detail.Stack = stack
+		detail.Fields = make(errorx.Fields, len(e.Fields))
+		for k, v := range e.Fields {
+			if k != tags.StackTrace {
+				detail.Fields[k] = v
+			}
+		}
+	}
+
+	return detail
 }
 
 func (e *ErrorDetail) Value() (driver.Value, error) {
@@ -78,9 +140,75 @@ func (e *ErrorDetail) Scan(value interface{}) error {
 	return jsonx.Unmarshal(b, &e)
 }
 
+type HistoryLogs struct {
+	Lines     []LogLine `db:"lines"     json:"lines,omitempty"`
+	Truncated bool      `db:"truncated" json:"truncated,omitempty"`
+}
+
+func (h *HistoryLogs) Value() (driver.Value, error) {
+	return jsonx.Marshal(h)
+}
+
+func (h *HistoryLogs) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errorx.E("type assertion to []byte failed")
+	}
+
+	return jsonx.Unmarshal(b, &h)
+}
+
+type LogLine struct {
+	Time    time.Time              `db:"time"    json:"time,omitempty"`
+	Level   string                 `db:"level"   json:"level,omitempty"`
+	Message string                 `db:"message" json:"message,omitempty"`
+	Fields  map[string]interface{} `db:"fields"  json:"fields,omitempty"`
+}
+
+// Result describes the outcome reported by a job run.
+// Example: processed 1,234 invoices, 3 skipped.
+type Result struct {
+	Counters map[string]int64  `db:"counters" json:"counters,omitempty"`
+	Values   map[string]string `db:"values"   json:"values,omitempty"`
+}
+
+func (r *Result) Value() (driver.Value, error) {
+	return jsonx.Marshal(r)
+}
+
+func (r *Result) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errorx.E("type assertion to []byte failed")
+	}
+
+	return jsonx.Unmarshal(b, &r)
+}
+
+// HistoryFilter describes which histories to read.
+// Empty Name and Status read the histories of every job name and status.
+// Zero Wave reads the histories of every wave.
 type HistoryFilter struct {
 	Sorts         sortx.Sorts `db:"sorts"          json:"sorts"`
 	Limit         int         `db:"limit"          json:"limit"`
 	StartingAfter *int64      `db:"starting_after" json:"starting_after"`
 	EndingBefore  *int64      `db:"ending_before"  json:"ending_before"`
+	Name          string      `db:"name"           json:"name"`
+	Status        string      `db:"status"         json:"status"`
+	Wave          int64       `db:"wave"           json:"wave"`
+}
+
+type AbandonFilter struct {
+	// MachineID limits the affected histories to the ones recorded by this machine.
+	MachineID string `db:"machine_id" json:"machine_id"`
+	// RunningStatus is the status of a history that has not been finished.
+	RunningStatus string `db:"running_status" json:"running_status"`
+	// StartedBefore limits the affected histories to the ones started before this time.
+	StartedBefore time.Time `db:"started_before" json:"started_before"`
+	// Status is the replacement status for the affected histories.
+	Status string `db:"status" json:"status"`
+	// StatusCode is the replacement status code for the affected histories.
+	StatusCode int64 `db:"status_code" json:"status_code"`
+	// FinishedAt is the finish time recorded for the affected histories.
+	FinishedAt time.Time `db:"finished_at" json:"finished_at"`
 }
@@ -2,8 +2,11 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/rizalgowandy/gdk/pkg/tags"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -63,6 +66,61 @@ func TestErrorDetailScanInvalidType(t *testing.T) {
 	assert.Contains(t, err.Error(), "type assertion to []byte failed")
 }
 
+func TestNewErrorDetail(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, ErrorDetail{}, NewErrorDetail(nil))
+	assert.Equal(t, ErrorDetail{Err: "plain"}, NewErrorDetail(errors.New("plain")))
+
+	got := NewErrorDetail(errorx.E("wrapped", errorx.CodeInternal, errorx.Fields{"id": 1}))
+	assert.Equal(t, "wrapped", got.Err)
+	assert.Equal(t, errorx.CodeInternal, got.Code)
+	assert.Equal(t, errorx.Fields{"id": 1}, got.Fields)
+
+	got = NewErrorDetail(errorx.E("there is a panic", errorx.Fields{
+		tags.StackTrace: []string{"main.go:10"},
+		tags.Panic:      "boom",
+	}))
+	assert.Equal(t, []string{"main.go:10"}, got.Stack)
+	assert.Equal(t, errorx.Fields{tags.Panic: "boom"}, got.Fields)
+}
+
+func TestHistoryLogsValueAndScan(t *testing.T) {
+	t.Parallel()
+
+	input := &HistoryLogs{
+		Lines: []LogLine{
+			{Level: "info", Message: "invoices processed"},
+		},
+		Truncated: true,
+	}
+
+	val, err := input.Value()
+	require.NoError(t, err)
+
+	var got HistoryLogs
+	err = got.Scan(val)
+	require.NoError(t, err)
+	assert.Equal(t, *input, got)
+}
+
+func TestResultValueAndScan(t *testing.T) {
+	t.Parallel()
+
+	input := &Result{
+		Counters: map[string]int64{"processed": 1234, "skipped": 3},
+		Values:   map[string]string{"batch": "2024-01"},
+	}
+
+	val, err := input.Value()
+	require.NoError(t, err)
+
+	var got Result
+	err = got.Scan(val)
+	require.NoError(t, err)
+	assert.Equal(t, *input, got)
+}
+
 func TestNoopClient(t *testing.T) {
 	t.Parallel()
 
@@ -70,6 +128,13 @@ func TestNoopClient(t *testing.T) {
 	err := c.WriteHistory(context.Background(), &History{})
 	require.NoError(t, err)
 
+	err = c.UpdateHistory(context.Background(), &History{})
+	require.NoError(t, err)
+
+	total, err := c.AbandonHistories(context.Background(), &AbandonFilter{})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
 	data, err := c.ReadHistories(context.Background(), &HistoryFilter{})
 	require.NoError(t, err)
 	assert.Nil(t, data)
@@ -0,0 +1,143 @@
+package cronx
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/rizalgowandy/cronx/storage"
+	"github.com/rizalgowandy/gdk/pkg/errorx/v2"
+	"github.com/rizalgowandy/gdk/pkg/logx"
+	"github.com/rizalgowandy/gdk/pkg/sortx"
+)
+
+// startWatchdog starts checking the jobs with a success window in the background.
+func (m *Manager) startWatchdog() {
+	if len(m.successWindows) == 0 || m.watchdogInterval <= 0 {
+		return
+	}
+
+	m.watchdogMu.Lock()
+	defer m.watchdogMu.Unlock()
+
+	if m.watchdogStop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	m.watchdogStop = stop
+
+	go m.watch(stop)
+}
+
+// stopWatchdog stops the watchdog, if running.
+func (m *Manager) stopWatchdog() {
+	m.watchdogMu.Lock()
+	defer m.watchdogMu.Unlock()
+
+	if m.watchdogStop == nil {
+		return
+	}
+	close(m.watchdogStop)
+	m.watchdogStop = nil
+}
+
+func (m *Manager) watch(stop <-chan struct{}) {
+	ticker := time.NewTicker(m.watchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			m.checkOverdue(logx.NewContext(), now)
+		}
+	}
+}
+
+// watchJob lets the watchdog check the job if it has a success window.
+// A job registered again with the same key replaces the previous one.
+func (m *Manager) watchJob(j *Job) {
+	if j.successWindow <= 0 {
+		return
+	}
+
+	m.watchdogMu.Lock()
+	defer m.watchdogMu.Unlock()
+
+	m.watched[j.Key()] = j
+}
+
+// watchedJobs returns the jobs with a success window ordered by the job key,
+// including the ones that have been removed, since a removed job never succeeds again.
+func (m *Manager) watchedJobs() []*Job {
+	m.watchdogMu.Lock()
+	defer m.watchdogMu.Unlock()
+
+	keys := make([]string, 0, len(m.watched))
+	for k := range m.watched {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	jobs := make([]*Job, 0, len(keys))
+	for _, k := range keys {
+		jobs = append(jobs, m.watched[k])
+	}
+	return jobs
+}
+
+// checkOverdue sends an alert for every job that has not succeeded within its success window.
+// The alert is repeated once per alert cooldown while the job stays overdue.
+func (m *Manager) checkOverdue(ctx context.Context, now time.Time) {
+	for _, j := range m.watchedJobs() {
+		if !j.UpdateOverdue(now) {
+			continue
+		}
+
+		// The job may have succeeded on another replica, or before the process has been restarted,
+		// so the last success is read from the storage before every alert.
+		m.loadLastSuccess(ctx, j)
+		if !j.UpdateOverdue(now) {
+			continue
+		}
+
+		fields := errorx.Fields{"success_window": j.SuccessWindow}
+		if last := j.lastSuccessTime(); !last.IsZero() {
+			fields["last_success"] = last.String()
+		}
+		m.notify(ctx, &Alert{
+			Event:  AlertEventOverdue,
+			Job:    j,
+			Fields: fields,
+			Time:   now,
+		})
+	}
+}
+
+// loadLastSuccess reads the last successful run of the job from the storage.
+// Jobs with multiple waves read the last successful run of their own wave.
+// The local last success is kept if the storage cannot be read.
+func (m *Manager) loadLastSuccess(ctx context.Context, j *Job) {
+	filter := &storage.HistoryFilter{
+		Sorts:  sortx.NewSorts("id:desc"),
+		Limit:  1,
+		Name:   j.Name,
+		Status: StatusCodeSuccess.String(),
+	}
+	if j.TotalWave > 1 {
+		filter.Wave = j.Wave
+	}
+
+	data, err := m.storage.ReadHistories(ctx, filter)
+	if err != nil {
+		if !errorx.Is(err, errorx.CodeNotFound) {
+			logx.ERR(ctx, errorx.E(err), "read last success history must success")
+		}
+		return
+	}
+
+	if len(data) > 0 {
+		j.observeSuccess(data[0].FinishedAt)
+	}
+}
@@ -0,0 +1,187 @@
+package cronx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rizalgowandy/cronx/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type successReader struct {
+	storageStub
+	filters []storage.HistoryFilter
+	data    []storage.History
+}
+
+func (s *successReader) ReadHistories(_ context.Context, req *storage.HistoryFilter) ([]storage.History, error) {
+	s.filters = append(s.filters, *req)
+	return s.data, nil
+}
+
+func TestManager_checkOverdue(t *testing.T) {
+	t.Parallel()
+
+	alerter := &alertRecorder{}
+	reader := &successReader{}
+	manager := NewManager(
+		WithAutoStartDisabled(),
+		WithAlerter(alerter),
+		WithStorage(reader),
+		WithAlertCooldown(time.Hour),
+		WithSuccessWindow("payBill", time.Hour),
+	)
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", func(context.Context) error { return nil }))
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "sendInvoice", func(context.Context) error { return nil }))
+
+	job := manager.GetEntries()[0].Job.(*Job)
+	assert.Equal(t, "1h0m0s", job.SuccessWindow)
+	now := time.Now()
+
+	// Within the window since the job is scheduled, the storage is not read.
+	manager.checkOverdue(context.Background(), now)
+	assert.Empty(t, alerter.alerts)
+	assert.False(t, job.Overdue)
+	assert.Empty(t, reader.filters)
+
+	// The window has passed without success, the storage is read before the alert.
+	manager.checkOverdue(context.Background(), now.Add(2*time.Hour))
+	assert.Equal(t, []AlertEvent{AlertEventOverdue}, alerter.events())
+	assert.Equal(t, "Operation cron payBill has not succeeded within 1h0m0s", alerter.alerts[0].Message())
+	assert.True(t, job.Overdue)
+	require.Len(t, reader.filters, 1)
+	assert.Equal(t, "payBill", reader.filters[0].Name)
+	assert.Equal(t, StatusCodeSuccess.String(), reader.filters[0].Status)
+
+	// The same alert is suppressed until the cooldown has passed.
+	manager.checkOverdue(context.Background(), now.Add(2*time.Hour+time.Minute))
+	assert.Len(t, alerter.alerts, 1)
+
+	// A successful run clears the overdue.
+	job.observeSuccess(now.Add(2 * time.Hour))
+	manager.checkOverdue(context.Background(), now.Add(2*time.Hour+time.Minute))
+	assert.False(t, job.Overdue)
+	assert.Len(t, alerter.alerts, 1)
+	assert.Len(t, reader.filters, 2)
+}
+
+func TestManager_checkOverdueOnAnotherReplica(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	alerter := &alertRecorder{}
+	reader := &successReader{}
+	manager := NewManager(
+		WithAutoStartDisabled(),
+		WithAlerter(alerter),
+		WithStorage(reader),
+		WithAlertCooldown(time.Hour),
+		WithSuccessWindow("payBill", time.Hour),
+	)
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", func(context.Context) error { return nil }))
+	job := manager.GetEntries()[0].Job.(*Job)
+	job.SetSuccessWindow(time.Hour, now)
+
+	// The runs of this replica keep losing the lock, while another replica keeps succeeding.
+	for i := range 5 {
+		at := now.Add(time.Duration(i+2) * time.Hour)
+		reader.data = []storage.History{{Name: "payBill", FinishedAt: at.Add(-10 * time.Minute)}}
+		manager.checkOverdue(context.Background(), at)
+	}
+	assert.Empty(t, alerter.alerts)
+	assert.False(t, job.Overdue)
+	assert.Len(t, reader.filters, 5)
+}
+
+func TestManager_checkOverdueRemoved(t *testing.T) {
+	t.Parallel()
+
+	alerter := &alertRecorder{}
+	manager := NewManager(
+		WithAutoStartDisabled(),
+		WithAlerter(alerter),
+		WithStorage(&successReader{}),
+		WithSuccessWindow("payBill", time.Hour),
+	)
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", func(context.Context) error { return nil }))
+	manager.Remove(manager.GetEntries()[0].ID)
+	require.Empty(t, manager.GetEntries())
+
+	// A removed job never succeeds again.
+	manager.checkOverdue(context.Background(), time.Now().Add(2*time.Hour))
+	assert.Equal(t, []AlertEvent{AlertEventOverdue}, alerter.events())
+	assert.Equal(t, "payBill", alerter.alerts[0].Job.Name)
+}
+
+func TestManager_checkOverdueFromStorage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	alerter := &alertRecorder{}
+	reader := &successReader{
+		data: []storage.History{{Name: "payBill", FinishedAt: now.Add(-30 * time.Minute)}},
+	}
+	manager := NewManager(
+		WithAutoStartDisabled(),
+		WithAlerter(alerter),
+		WithStorage(reader),
+		WithSuccessWindow("payBill", time.Hour),
+	)
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "payBill", func(context.Context) error { return nil }))
+	job := manager.GetEntries()[0].Job.(*Job)
+	job.SetSuccessWindow(time.Hour, now.Add(-2*time.Hour))
+
+	manager.checkOverdue(context.Background(), now)
+	assert.Empty(t, alerter.alerts)
+	assert.False(t, job.Overdue)
+	assert.True(t, job.LastSuccess.Equal(now.Add(-30*time.Minute)))
+
+	manager.checkOverdue(context.Background(), now.Add(time.Hour))
+	assert.Equal(t, []AlertEvent{AlertEventOverdue}, alerter.events())
+}
+
+func TestManager_Watchdog(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(WithAutoStartDisabled())
+	manager.Start()
+	assert.Nil(t, manager.watchdogStop)
+	manager.Stop()
+
+	manager = NewManager(
+		WithAutoStartDisabled(),
+		WithSuccessWindow("payBill", time.Hour),
+		WithWatchdogInterval(time.Millisecond),
+	)
+	manager.Start()
+	manager.Start()
+	assert.NotNil(t, manager.watchdogStop)
+	manager.Stop()
+	manager.Stop()
+	assert.Nil(t, manager.watchdogStop)
+}
+
+func TestManager_checkOverduePerWave(t *testing.T) {
+	t.Parallel()
+
+	reader := &successReader{}
+	manager := NewManager(
+		WithAutoStartDisabled(),
+		WithStorage(reader),
+		WithSuccessWindow("payBill", time.Hour),
+		WithSuccessWindow("sendInvoice", time.Hour),
+	)
+	require.NoError(t, manager.SchedulesFunc("@every 5m#@every 10m", "#", "payBill", func(context.Context) error { return nil }))
+	require.NoError(t, manager.ScheduleFunc("@every 5m", "sendInvoice", func(context.Context) error { return nil }))
+
+	// A success in one wave must not hide a stalled wave.
+	manager.checkOverdue(context.Background(), time.Now().Add(2*time.Hour))
+	waves := map[string][]int64{}
+	for _, v := range reader.filters {
+		waves[v.Name] = append(waves[v.Name], v.Wave)
+	}
+	assert.ElementsMatch(t, []int64{1, 2}, waves["payBill"])
+	assert.Equal(t, []int64{0}, waves["sendInvoice"])
+}